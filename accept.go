@@ -0,0 +1,75 @@
+package response
+
+import (
+	"strconv"
+	"strings"
+)
+
+// mediaRange is a media range of an Accept header
+type mediaRange struct {
+	mediaType string
+	params    []string
+	quality   float64
+}
+
+// parseAccept returns the media ranges of an Accept header in the order they are listed.
+// Media types are lower cased, the media type parameters are kept as written and a range without q has the quality 1.
+func parseAccept(accept string) []mediaRange {
+	var ranges []mediaRange
+	for _, part := range strings.Split(accept, ",") {
+		params := strings.Split(part, ";")
+		accepted := mediaRange{mediaType: strings.ToLower(strings.TrimSpace(params[0])), quality: 1}
+		if accepted.mediaType == "" {
+			continue
+		}
+		for _, param := range params[1:] {
+			name, value, _ := strings.Cut(param, "=")
+			// q and the parameters after it are accept parameters, not media type parameters
+			if strings.EqualFold(strings.TrimSpace(name), "q") {
+				if quality, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil {
+					accepted.quality = quality
+				}
+				break
+			}
+			accepted.params = append(accepted.params, strings.TrimSpace(param))
+		}
+		ranges = append(ranges, accepted)
+	}
+	return ranges
+}
+
+// acceptQuality returns the highest quality of the ranges which name a media type matched by matches,
+// zero when none is listed or all are listed with q=0. Wildcard ranges are not counted.
+func acceptQuality(ranges []mediaRange, matches func(mediaType string) bool) float64 {
+	quality := 0.0
+	for _, accepted := range ranges {
+		if matches(accepted.mediaType) {
+			quality = max(quality, accepted.quality)
+		}
+	}
+	return quality
+}
+
+// negotiateMediaType returns the index of the offer with the highest quality in the ranges,
+// the first one on a tie, or -1 when no offer is acceptable
+func negotiateMediaType(ranges []mediaRange, offers ...func(mediaType string) bool) int {
+	offer, best := -1, 0.0
+	for i, matches := range offers {
+		if quality := acceptQuality(ranges, matches); quality > best {
+			offer, best = i, quality
+		}
+	}
+	return offer
+}
+
+// isMediaType returns a matcher of the media type
+func isMediaType(mediaType string) func(string) bool {
+	return func(accepted string) bool {
+		return accepted == mediaType
+	}
+}
+
+// isJSONMediaType reports whether the media type is application/json or has the +json structured syntax suffix
+func isJSONMediaType(mediaType string) bool {
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
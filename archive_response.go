@@ -0,0 +1,277 @@
+package response
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ArchiveResponse is used to send a ZIP archive which is written directly to the client without temporary files.
+// Entries can be added from disk paths (AddFile), readers (AddReader), byte slices (AddBytes) and file systems (AddFS),
+// they are opened one at a time while the archive is written.
+// When the client disconnects, writing stops and the handler is aborted with [http.ErrAbortHandler].
+type ArchiveResponse struct {
+	*Response
+	filename  string
+	method    uint16
+	methodSet bool
+	entries   []archiveEntry
+}
+
+// archiveEntry is a lazily opened archive member, a zero modTime or mode is taken from the opened file.
+// Directories and symbolic links carry their info and have no open func, size is -1 when unknown.
+type archiveEntry struct {
+	name    string
+	modTime time.Time
+	mode    fs.FileMode
+	info    fs.FileInfo
+	link    string
+	size    int64
+	open    func() (io.ReadCloser, fs.FileInfo, error)
+}
+
+// SetFilename sets the download filename
+func (archiveResponse *ArchiveResponse) SetFilename(filename string) *ArchiveResponse {
+	archiveResponse.filename = filename
+	return archiveResponse
+}
+
+// SetCompression sets the compression method of the entries, [zip.Deflate] (default) or [zip.Store]
+func (archiveResponse *ArchiveResponse) SetCompression(method uint16) *ArchiveResponse {
+	archiveResponse.method = method
+	archiveResponse.methodSet = true
+	return archiveResponse
+}
+
+// AddFile adds a file from disk, the entry is named after the base name of the file unless name is given
+func (archiveResponse *ArchiveResponse) AddFile(file string, name ...string) *ArchiveResponse {
+	archiveResponse.entries = append(archiveResponse.entries, newFileArchiveEntry(file, name...))
+	return archiveResponse
+}
+
+// AddReader adds an entry read from reader, the reader is closed after use if it is an [io.Closer]
+func (archiveResponse *ArchiveResponse) AddReader(name string, reader io.Reader, modTime time.Time) *ArchiveResponse {
+	archiveResponse.entries = append(archiveResponse.entries, newReaderArchiveEntry(name, reader, modTime))
+	return archiveResponse
+}
+
+// AddBytes adds an entry with the given content
+func (archiveResponse *ArchiveResponse) AddBytes(name string, content []byte, modTime time.Time) *ArchiveResponse {
+	return archiveResponse.AddReader(name, bytes.NewReader(content), modTime)
+}
+
+// AddFS adds all files under root of fsys, entries are named by their path relative to root joined to prefix
+func (archiveResponse *ArchiveResponse) AddFS(fsys fs.FS, root, prefix string) *ArchiveResponse {
+	entries, err := newFSArchiveEntries(fsys, root, prefix)
+	if err != nil {
+		panic(err)
+	}
+	archiveResponse.entries = append(archiveResponse.entries, entries...)
+	return archiveResponse
+}
+
+// ServeHTTP writes the archive
+func (archiveResponse *ArchiveResponse) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	archiveResponse.send(w, r, archiveResponse.serve)
+}
+
+func (archiveResponse *ArchiveResponse) serve(w http.ResponseWriter, r *http.Request) {
+	archiveResponse.sendHeaders(w, r)
+	w.Header().Set("Content-Type", "application/zip")
+	setContentDisposition(w.Header(), archiveResponse.filename, "archive.zip")
+	w.WriteHeader(archiveResponse.statusCode)
+	// the status may not allow a body
+	if !bodyAllowedForStatus(archiveResponse.statusCode) {
+		return
+	}
+
+	ctx := r.Context()
+	zw := zip.NewWriter(&contextWriter{ctx: ctx, w: w})
+	method := uint16(zip.Deflate)
+	if archiveResponse.methodSet {
+		method = archiveResponse.method
+	}
+	for _, entry := range archiveResponse.entries {
+		err := writeZipEntry(zw, entry, method)
+		abortOnError(ctx, err)
+	}
+	abortOnError(ctx, zw.Close())
+}
+
+func writeZipEntry(zw *zip.Writer, entry archiveEntry, method uint16) error {
+	reader, info, err := entry.open()
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	header := &zip.FileHeader{
+		Name:     entry.name,
+		Method:   method,
+		Modified: entry.modTime,
+	}
+	mode := entry.mode
+	if info != nil {
+		if header.Modified.IsZero() {
+			header.Modified = info.ModTime()
+		}
+		if mode == 0 {
+			mode = info.Mode()
+		}
+	}
+	if header.Modified.IsZero() {
+		header.Modified = time.Now()
+	}
+	if mode != 0 {
+		header.SetMode(mode)
+	}
+	writer, err := zw.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(writer, reader)
+	return err
+}
+
+func newFileArchiveEntry(file string, name ...string) archiveEntry {
+	entryName := filepath.Base(file)
+	if len(name) > 0 && name[0] != "" {
+		entryName = name[0]
+	}
+	return archiveEntry{
+		name: archiveEntryName(entryName),
+		open: func() (io.ReadCloser, fs.FileInfo, error) {
+			f, err := os.Open(file)
+			if err != nil {
+				return nil, nil, err
+			}
+			info, err := f.Stat()
+			if err != nil {
+				_ = f.Close()
+				return nil, nil, err
+			}
+			return f, info, nil
+		},
+	}
+}
+
+func newReaderArchiveEntry(name string, reader io.Reader, modTime time.Time) archiveEntry {
+	return archiveEntry{
+		name:    archiveEntryName(name),
+		modTime: modTime,
+		mode:    0o644,
+		size:    readerSize(reader),
+		open: func() (io.ReadCloser, fs.FileInfo, error) {
+			if closer, ok := reader.(io.ReadCloser); ok {
+				return closer, nil, nil
+			}
+			return io.NopCloser(reader), nil, nil
+		},
+	}
+}
+
+// newFSArchiveEntries walks root of fsys and returns an entry for every regular file
+func newFSArchiveEntries(fsys fs.FS, root, prefix string) ([]archiveEntry, error) {
+	var entries []archiveEntry
+	err := fs.WalkDir(fsys, root, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel := strings.TrimPrefix(strings.TrimPrefix(name, root), "/")
+		if root == "." {
+			rel = name
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		if rel == "" {
+			rel = path.Base(name)
+		}
+		entries = append(entries, archiveEntry{
+			name: archiveEntryName(path.Join(prefix, rel)),
+			open: func() (io.ReadCloser, fs.FileInfo, error) {
+				f, err := fsys.Open(name)
+				if err != nil {
+					return nil, nil, err
+				}
+				info, err := f.Stat()
+				if err != nil {
+					_ = f.Close()
+					return nil, nil, err
+				}
+				return f, info, nil
+			},
+		})
+		return nil
+	})
+	return entries, err
+}
+
+// readerSize returns the remaining length of reader without consuming it, or -1 when it can not be known
+func readerSize(reader io.Reader) int64 {
+	switch r := reader.(type) {
+	case interface{ Len() int }:
+		return int64(r.Len())
+	case io.Seeker:
+		current, err := r.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return -1
+		}
+		end, err := r.Seek(0, io.SeekEnd)
+		if err != nil {
+			return -1
+		}
+		if _, err := r.Seek(current, io.SeekStart); err != nil {
+			return -1
+		}
+		return end - current
+	}
+	return -1
+}
+
+// archiveEntryName returns a relative slash-separated name which can not escape the extraction directory
+func archiveEntryName(name string) string {
+	name = path.Clean("/" + strings.ReplaceAll(name, `\`, "/"))
+	return strings.TrimPrefix(name, "/")
+}
+
+// setContentDisposition sets an attachment Content-Disposition header
+func setContentDisposition(header http.Header, filename, fallback string) {
+	if filename == "" {
+		filename = fallback
+	}
+	header.Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+}
+
+// abortOnError stops a response whose body is partially written, a disconnected client aborts the handler silently
+func abortOnError(ctx context.Context, err error) {
+	if err == nil {
+		return
+	}
+	if ctx.Err() != nil || errors.Is(err, context.Canceled) {
+		panic(http.ErrAbortHandler)
+	}
+	panic(err)
+}
+
+// contextWriter fails writes once the context is done
+type contextWriter struct {
+	ctx context.Context
+	w   io.Writer
+}
+
+func (writer *contextWriter) Write(p []byte) (int, error) {
+	if err := writer.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return writer.w.Write(p)
+}
@@ -0,0 +1,138 @@
+package response
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func readZip(t *testing.T, body []byte) map[string]*zip.File {
+	reader, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+	if err != nil {
+		assert.FailNow(t, err.Error())
+	}
+	files := make(map[string]*zip.File)
+	for _, f := range reader.File {
+		files[f.Name] = f
+	}
+	return files
+}
+
+func readZipFile(t *testing.T, f *zip.File) string {
+	rc, err := f.Open()
+	if err != nil {
+		assert.FailNow(t, err.Error())
+	}
+	defer rc.Close()
+	content, err := io.ReadAll(rc)
+	if err != nil {
+		assert.FailNow(t, err.Error())
+	}
+	return string(content)
+}
+
+func TestArchiveResponse(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "report.csv")
+	if err := os.WriteFile(file, []byte("id,name\n1,John\n"), 0o600); err != nil {
+		assert.FailNow(t, err.Error())
+	}
+	modTime := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	fsys := fstest.MapFS{
+		"assets/a.txt":     {Data: []byte("a"), ModTime: modTime},
+		"assets/sub/b.txt": {Data: []byte("b"), ModTime: modTime},
+		"other.txt":        {Data: []byte("other")},
+	}
+
+	response := New(200).Zip("reports.zip")
+	response.AddFile(file).
+		AddFile(file, "renamed/report.csv").
+		AddBytes("../notes.txt", []byte("hello"), modTime).
+		AddReader("reader.txt", bytes.NewBufferString("from reader"), modTime).
+		AddFS(fsys, "assets", "static")
+
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest("GET", "/", nil)
+	response.ServeHTTP(recorder, request)
+
+	result := recorder.Result()
+	assert.Equal(t, 200, result.StatusCode)
+	assert.Equal(t, "application/zip", result.Header.Get("Content-Type"))
+	assert.Equal(t, `attachment; filename=reports.zip`, result.Header.Get("Content-Disposition"))
+
+	files := readZip(t, recorder.Body.Bytes())
+	assert.Len(t, files, 6)
+	assert.Equal(t, "id,name\n1,John\n", readZipFile(t, files["report.csv"]))
+	assert.Equal(t, "id,name\n1,John\n", readZipFile(t, files["renamed/report.csv"]))
+	assert.Equal(t, "hello", readZipFile(t, files["notes.txt"]))
+	assert.Equal(t, "from reader", readZipFile(t, files["reader.txt"]))
+	assert.Equal(t, "a", readZipFile(t, files["static/a.txt"]))
+	assert.Equal(t, "b", readZipFile(t, files["static/sub/b.txt"]))
+	assert.True(t, modTime.Equal(files["notes.txt"].Modified))
+	assert.True(t, modTime.Equal(files["static/a.txt"].Modified))
+	assert.Equal(t, zip.Deflate, files["notes.txt"].Method)
+}
+
+// serveOverHTTP serves the handler with a real server, which unlike a recorder rejects bodies for 204 and 304
+func serveOverHTTP(t *testing.T, handler http.Handler) (*http.Response, string) {
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	result, err := http.Get(server.URL)
+	if err != nil {
+		assert.FailNow(t, err.Error())
+	}
+	defer result.Body.Close()
+	body, err := io.ReadAll(result.Body)
+	assert.Nil(t, err)
+	return result, string(body)
+}
+
+func TestArchiveResponse_NoContent(t *testing.T) {
+	result, body := serveOverHTTP(t, New(http.StatusNoContent).Zip("empty.zip").AddBytes("a.txt", []byte("a"), time.Time{}))
+	assert.Equal(t, http.StatusNoContent, result.StatusCode)
+	assert.Empty(t, body)
+}
+
+func TestArchiveResponse_Store(t *testing.T) {
+	response := New(200).Zip("")
+	response.SetCompression(zip.Store).AddBytes("a.txt", []byte("a"), time.Time{})
+
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest("GET", "/", nil)
+	response.ServeHTTP(recorder, request)
+
+	assert.Equal(t, `attachment; filename=archive.zip`, recorder.Header().Get("Content-Disposition"))
+	files := readZip(t, recorder.Body.Bytes())
+	assert.Equal(t, zip.Store, files["a.txt"].Method)
+}
+
+func TestArchiveResponse_ClientDisconnected(t *testing.T) {
+	response := New(200).Zip("reports.zip")
+	response.AddBytes("a.txt", []byte("a"), time.Time{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest("GET", "/", nil).WithContext(ctx)
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		response.ServeHTTP(recorder, request)
+	})
+}
+
+func TestArchiveResponse_FileNotExists(t *testing.T) {
+	response := New(200).Zip("reports.zip")
+	response.AddFile("not-exists.txt")
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest("GET", "/", nil)
+	assert.Panics(t, func() { response.ServeHTTP(recorder, request) })
+}
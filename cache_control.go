@@ -0,0 +1,278 @@
+package response
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Cache-Control directives
+const (
+	CacheMaxAge               = "max-age"
+	CacheSMaxAge              = "s-maxage"
+	CacheMaxStale             = "max-stale"
+	CacheMinFresh             = "min-fresh"
+	CacheStaleWhileRevalidate = "stale-while-revalidate"
+	CacheStaleIfError         = "stale-if-error"
+	CacheNoCache              = "no-cache"
+	CacheNoStore              = "no-store"
+	CacheNoTransform          = "no-transform"
+	CacheOnlyIfCached         = "only-if-cached"
+	CacheMustRevalidate       = "must-revalidate"
+	CacheProxyRevalidate      = "proxy-revalidate"
+	CacheMustUnderstand       = "must-understand"
+	CachePublic               = "public"
+	CachePrivate              = "private"
+	CacheImmutable            = "immutable"
+)
+
+// CacheControl is used to build and inspect the Cache-Control header.
+// The builder methods return the receiver so calls can be chained:
+//
+//	response.NewCacheControl().Public().MaxAge(10 * time.Minute).StaleWhileRevalidate(30 * time.Second)
+//
+// Directives keep the order in which they are first set, and unknown directives are kept as is.
+type CacheControl struct {
+	names  []string
+	values map[string]string
+}
+
+// NewCacheControl creates an empty [CacheControl]
+func NewCacheControl() *CacheControl {
+	return &CacheControl{values: make(map[string]string)}
+}
+
+// NeverCache returns a Cache-Control which forbids caching the response
+func NeverCache() *CacheControl {
+	return NewCacheControl().NoStore()
+}
+
+// StaticAsset returns a Cache-Control for fingerprinted assets which never change
+func StaticAsset(maxAge time.Duration) *CacheControl {
+	return NewCacheControl().Public().MaxAge(maxAge).Immutable()
+}
+
+// UserPrivate returns a Cache-Control for responses which only the user agent may cache,
+// the response is revalidated on every use when maxAge is zero
+func UserPrivate(maxAge time.Duration) *CacheControl {
+	cacheControl := NewCacheControl().Private()
+	if maxAge <= 0 {
+		return cacheControl.NoCache()
+	}
+	return cacheControl.MaxAge(maxAge)
+}
+
+// ParseCacheControl parses a Cache-Control header value, directive names are case-insensitive
+func ParseCacheControl(value string) *CacheControl {
+	cacheControl := NewCacheControl()
+	for len(value) > 0 {
+		var part string
+		part, value = cutDirective(value)
+		name, arg, _ := strings.Cut(part, "=")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		arg = strings.TrimSpace(arg)
+		if len(arg) >= 2 && arg[0] == '"' && arg[len(arg)-1] == '"' {
+			arg = arg[1 : len(arg)-1]
+		}
+		cacheControl.Set(name, arg)
+	}
+	return cacheControl
+}
+
+// cutDirective returns the first comma separated directive of value, commas in quoted strings are kept
+func cutDirective(value string) (string, string) {
+	quoted := false
+	for i := 0; i < len(value); i++ {
+		switch value[i] {
+		case '"':
+			quoted = !quoted
+		case ',':
+			if !quoted {
+				return value[:i], value[i+1:]
+			}
+		}
+	}
+	return value, ""
+}
+
+// Set sets a directive, value is empty for directives without argument
+func (cacheControl *CacheControl) Set(name, value string) *CacheControl {
+	name = strings.ToLower(name)
+	if _, ok := cacheControl.values[name]; !ok {
+		cacheControl.names = append(cacheControl.names, name)
+	}
+	cacheControl.values[name] = value
+	return cacheControl
+}
+
+// Remove removes a directive
+func (cacheControl *CacheControl) Remove(name string) *CacheControl {
+	name = strings.ToLower(name)
+	if _, ok := cacheControl.values[name]; ok {
+		delete(cacheControl.values, name)
+		cacheControl.names = slices.DeleteFunc(cacheControl.names, func(n string) bool { return n == name })
+	}
+	return cacheControl
+}
+
+// Has returns if the directive is set
+func (cacheControl *CacheControl) Has(name string) bool {
+	_, ok := cacheControl.values[strings.ToLower(name)]
+	return ok
+}
+
+// Value returns the argument of a directive
+func (cacheControl *CacheControl) Value(name string) (string, bool) {
+	value, ok := cacheControl.values[strings.ToLower(name)]
+	return value, ok
+}
+
+// Duration returns the argument of a delta-seconds directive such as max-age,
+// it reports false when the directive is not set or its argument is not a number
+func (cacheControl *CacheControl) Duration(name string) (time.Duration, bool) {
+	value, ok := cacheControl.Value(name)
+	if !ok {
+		return 0, false
+	}
+	seconds, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || seconds < 0 {
+		return 0, false
+	}
+	return time.Duration(seconds) * time.Second, true
+}
+
+// Directives returns the directive names in order
+func (cacheControl *CacheControl) Directives() []string {
+	return slices.Clone(cacheControl.names)
+}
+
+// String returns the header value
+func (cacheControl *CacheControl) String() string {
+	parts := make([]string, 0, len(cacheControl.names))
+	for _, name := range cacheControl.names {
+		value := cacheControl.values[name]
+		switch {
+		case value == "":
+			parts = append(parts, name)
+		case strings.ContainsAny(value, ", \t\""):
+			parts = append(parts, name+"="+strconv.Quote(value))
+		default:
+			parts = append(parts, name+"="+value)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Public sets the public directive and removes the private directive
+func (cacheControl *CacheControl) Public() *CacheControl {
+	return cacheControl.Remove(CachePrivate).Set(CachePublic, "")
+}
+
+// Private sets the private directive and removes the public directive,
+// fields limits the directive to the named header fields
+func (cacheControl *CacheControl) Private(fields ...string) *CacheControl {
+	return cacheControl.Remove(CachePublic).Set(CachePrivate, strings.Join(fields, ", "))
+}
+
+// NoCache sets the no-cache directive, fields limits the directive to the named header fields
+func (cacheControl *CacheControl) NoCache(fields ...string) *CacheControl {
+	return cacheControl.Set(CacheNoCache, strings.Join(fields, ", "))
+}
+
+// NoStore sets the no-store directive
+func (cacheControl *CacheControl) NoStore() *CacheControl {
+	return cacheControl.Set(CacheNoStore, "")
+}
+
+// NoTransform sets the no-transform directive
+func (cacheControl *CacheControl) NoTransform() *CacheControl {
+	return cacheControl.Set(CacheNoTransform, "")
+}
+
+// MustRevalidate sets the must-revalidate directive
+func (cacheControl *CacheControl) MustRevalidate() *CacheControl {
+	return cacheControl.Set(CacheMustRevalidate, "")
+}
+
+// ProxyRevalidate sets the proxy-revalidate directive
+func (cacheControl *CacheControl) ProxyRevalidate() *CacheControl {
+	return cacheControl.Set(CacheProxyRevalidate, "")
+}
+
+// MustUnderstand sets the must-understand directive
+func (cacheControl *CacheControl) MustUnderstand() *CacheControl {
+	return cacheControl.Set(CacheMustUnderstand, "")
+}
+
+// Immutable sets the immutable directive
+func (cacheControl *CacheControl) Immutable() *CacheControl {
+	return cacheControl.Set(CacheImmutable, "")
+}
+
+// OnlyIfCached sets the only-if-cached request directive
+func (cacheControl *CacheControl) OnlyIfCached() *CacheControl {
+	return cacheControl.Set(CacheOnlyIfCached, "")
+}
+
+// MaxAge sets the max-age directive
+func (cacheControl *CacheControl) MaxAge(maxAge time.Duration) *CacheControl {
+	return cacheControl.setDuration(CacheMaxAge, maxAge)
+}
+
+// SMaxAge sets the s-maxage directive
+func (cacheControl *CacheControl) SMaxAge(maxAge time.Duration) *CacheControl {
+	return cacheControl.setDuration(CacheSMaxAge, maxAge)
+}
+
+// StaleWhileRevalidate sets the stale-while-revalidate directive
+func (cacheControl *CacheControl) StaleWhileRevalidate(duration time.Duration) *CacheControl {
+	return cacheControl.setDuration(CacheStaleWhileRevalidate, duration)
+}
+
+// StaleIfError sets the stale-if-error directive
+func (cacheControl *CacheControl) StaleIfError(duration time.Duration) *CacheControl {
+	return cacheControl.setDuration(CacheStaleIfError, duration)
+}
+
+// MaxStale sets the max-stale request directive
+func (cacheControl *CacheControl) MaxStale(duration time.Duration) *CacheControl {
+	return cacheControl.setDuration(CacheMaxStale, duration)
+}
+
+// MinFresh sets the min-fresh request directive
+func (cacheControl *CacheControl) MinFresh(duration time.Duration) *CacheControl {
+	return cacheControl.setDuration(CacheMinFresh, duration)
+}
+
+func (cacheControl *CacheControl) setDuration(name string, duration time.Duration) *CacheControl {
+	if duration < 0 {
+		duration = 0
+	}
+	return cacheControl.Set(name, strconv.FormatInt(int64(duration/time.Second), 10))
+}
+
+// apply writes Cache-Control and a matching Expires header to header, an explicit Expires header is kept.
+// The Expires time is reduced by the Age header when the response comes from a cache.
+func (cacheControl *CacheControl) apply(header http.Header, now time.Time) {
+	header.Set("Cache-Control", cacheControl.String())
+	if header.Get("Expires") != "" {
+		return
+	}
+	if cacheControl.Has(CacheNoStore) || cacheControl.Has(CacheNoCache) {
+		header.Set("Expires", time.Unix(0, 0).UTC().Format(http.TimeFormat))
+		return
+	}
+	maxAge, ok := cacheControl.Duration(CacheMaxAge)
+	if !ok {
+		return
+	}
+	if age, err := strconv.ParseInt(header.Get("Age"), 10, 64); err == nil && age > 0 {
+		maxAge -= time.Duration(age) * time.Second
+	}
+	header.Set("Expires", now.Add(maxAge).UTC().Format(http.TimeFormat))
+}
@@ -0,0 +1,84 @@
+package response
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCacheControl_String(t *testing.T) {
+	cacheControl := NewCacheControl().Public().MaxAge(10 * time.Minute).StaleWhileRevalidate(30 * time.Second)
+	assert.Equal(t, "public, max-age=600, stale-while-revalidate=30", cacheControl.String())
+
+	cacheControl.Private("Set-Cookie", "X-Token")
+	assert.Equal(t, `max-age=600, stale-while-revalidate=30, private="Set-Cookie, X-Token"`, cacheControl.String())
+
+	assert.Equal(t, "no-store", NeverCache().String())
+	assert.Equal(t, "public, max-age=31536000, immutable", StaticAsset(365*24*time.Hour).String())
+	assert.Equal(t, "private, no-cache", UserPrivate(0).String())
+	assert.Equal(t, "private, max-age=60", UserPrivate(time.Minute).String())
+}
+
+func TestParseCacheControl(t *testing.T) {
+	cacheControl := ParseCacheControl(`Public, MAX-AGE=600, s-maxage="60", no-cache="Set-Cookie, X-Token", foo=bar,,`)
+	assert.Equal(t, []string{"public", "max-age", "s-maxage", "no-cache", "foo"}, cacheControl.Directives())
+	assert.True(t, cacheControl.Has(CachePublic))
+	maxAge, ok := cacheControl.Duration(CacheMaxAge)
+	assert.True(t, ok)
+	assert.Equal(t, 10*time.Minute, maxAge)
+	sMaxAge, ok := cacheControl.Duration(CacheSMaxAge)
+	assert.True(t, ok)
+	assert.Equal(t, time.Minute, sMaxAge)
+	fields, _ := cacheControl.Value(CacheNoCache)
+	assert.Equal(t, "Set-Cookie, X-Token", fields)
+	_, ok = cacheControl.Duration("foo")
+	assert.False(t, ok)
+	_, ok = cacheControl.Duration(CacheStaleIfError)
+	assert.False(t, ok)
+}
+
+func TestResponse_SetCacheControl(t *testing.T) {
+	t.Run("MaxAge", func(t *testing.T) {
+		response := New(200, "ok")
+		response.SetCacheControl(NewCacheControl().Public().MaxAge(time.Hour))
+		response.SetAge(10 * time.Minute)
+		response.Vary("Accept-Encoding", "Accept")
+		response.Vary("accept")
+
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest("GET", "/", nil)
+		before := time.Now()
+		response.ServeHTTP(recorder, request)
+
+		result := recorder.Result()
+		assert.Equal(t, "public, max-age=3600", result.Header.Get("Cache-Control"))
+		assert.Equal(t, "600", result.Header.Get("Age"))
+		assert.Equal(t, []string{"Accept-Encoding", "Accept"}, result.Header.Values("Vary"))
+		expires, err := http.ParseTime(result.Header.Get("Expires"))
+		assert.Nil(t, err)
+		assert.WithinDuration(t, before.Add(50*time.Minute), expires, 2*time.Second)
+	})
+
+	t.Run("NeverCache", func(t *testing.T) {
+		response := New(200, "ok")
+		response.SetCacheControl(NeverCache())
+
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest("GET", "/", nil)
+		response.ServeHTTP(recorder, request)
+
+		result := recorder.Result()
+		assert.Equal(t, "no-store", result.Header.Get("Cache-Control"))
+		assert.Equal(t, "Thu, 01 Jan 1970 00:00:00 GMT", result.Header.Get("Expires"))
+	})
+
+	t.Run("ParseHeader", func(t *testing.T) {
+		response := New(200)
+		assert.Nil(t, response.CacheControl())
+		response.SetHeader("Cache-Control", "private, max-age=5")
+		assert.True(t, response.CacheControl().Has(CachePrivate))
+	})
+}
@@ -0,0 +1,152 @@
+package response
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gopi-frame/exception"
+)
+
+// MaxCookieSize is the size limit in bytes that browsers enforce on a single
+// serialized cookie (name, value and attributes).
+const MaxCookieSize = 4096
+
+const (
+	hostCookiePrefix   = "__Host-"
+	secureCookiePrefix = "__Secure-"
+)
+
+var defaultCookiePolicy *CookiePolicy
+
+// SetDefaultCookiePolicy sets the cookie policy applied to responses
+// which have no policy of their own, pass nil to disable it.
+func SetDefaultCookiePolicy(policy *CookiePolicy) {
+	defaultCookiePolicy = policy
+}
+
+// DefaultCookiePolicy returns the global cookie policy
+func DefaultCookiePolicy() *CookiePolicy {
+	return defaultCookiePolicy
+}
+
+// CookiePolicy describes the defaults and rules applied to response cookies before they are sent.
+//   - Secure and HttpOnly: When true, every cookie is sent with the corresponding attribute.
+//   - SameSite, Path and Domain: Used when the cookie does not set its own value.
+//   - MaxSize: The size limit of a serialized cookie, [MaxCookieSize] is used when it is zero.
+//
+// Cookies named with the `__Secure-` prefix are always sent as secure, and cookies named
+// with the `__Host-` prefix are always sent as secure with path `/` and without domain,
+// an explicit attribute conflicting with these rules is reported as an error.
+// Cookies sharing the same name, path and domain are deduplicated, the last one wins.
+type CookiePolicy struct {
+	Secure   bool
+	HttpOnly bool
+	SameSite http.SameSite
+	Path     string
+	Domain   string
+	MaxSize  int
+}
+
+// Apply returns copies of the cookies with the policy applied,
+// the given cookies are not modified.
+func (policy *CookiePolicy) Apply(cookies []*http.Cookie) ([]*http.Cookie, error) {
+	result := make([]*http.Cookie, 0, len(cookies))
+	for _, cookie := range cookies {
+		c := *cookie
+		policy.applyDefaults(&c)
+		if err := policy.validate(&c); err != nil {
+			return nil, err
+		}
+		result = append(result, &c)
+	}
+	return dedupeCookies(result), nil
+}
+
+// dedupeCookies removes the cookies overridden by a later cookie sharing the same name, path and domain,
+// the later cookie takes the position of the first one
+func dedupeCookies(cookies []*http.Cookie) []*http.Cookie {
+	result := make([]*http.Cookie, 0, len(cookies))
+	positions := make(map[string]int, len(cookies))
+	for _, cookie := range cookies {
+		key := strings.Join([]string{cookie.Name, cookie.Path, strings.ToLower(strings.TrimPrefix(cookie.Domain, "."))}, ";")
+		if position, ok := positions[key]; ok {
+			result[position] = cookie
+			continue
+		}
+		positions[key] = len(result)
+		result = append(result, cookie)
+	}
+	return result
+}
+
+func (policy *CookiePolicy) applyDefaults(cookie *http.Cookie) {
+	if policy.Secure {
+		cookie.Secure = true
+	}
+	if policy.HttpOnly {
+		cookie.HttpOnly = true
+	}
+	if cookie.SameSite == 0 {
+		cookie.SameSite = policy.SameSite
+	}
+	switch {
+	case strings.HasPrefix(cookie.Name, hostCookiePrefix):
+		cookie.Secure = true
+		if cookie.Path == "" {
+			cookie.Path = "/"
+		}
+	case strings.HasPrefix(cookie.Name, secureCookiePrefix):
+		cookie.Secure = true
+		fallthrough
+	default:
+		if cookie.Path == "" {
+			cookie.Path = policy.Path
+		}
+		if cookie.Domain == "" {
+			cookie.Domain = policy.Domain
+		}
+	}
+}
+
+func (policy *CookiePolicy) validate(cookie *http.Cookie) error {
+	if strings.HasPrefix(cookie.Name, hostCookiePrefix) {
+		if cookie.Domain != "" {
+			return exception.New(fmt.Sprintf("cookie `%s` must not set a domain", cookie.Name))
+		}
+		if cookie.Path != "/" {
+			return exception.New(fmt.Sprintf("cookie `%s` must use path `/`", cookie.Name))
+		}
+	}
+	if cookie.SameSite == http.SameSiteNoneMode && !cookie.Secure {
+		return exception.New(fmt.Sprintf("cookie `%s` with SameSite=None must be secure", cookie.Name))
+	}
+	maxSize := policy.MaxSize
+	if maxSize <= 0 {
+		maxSize = MaxCookieSize
+	}
+	if size := len(cookie.String()); size > maxSize {
+		return exception.New(fmt.Sprintf("cookie `%s` is %d bytes, exceeds the limit of %d bytes", cookie.Name, size, maxSize))
+	}
+	return nil
+}
+
+// newForgottenCookie returns a cookie which tells the client to delete the named cookie,
+// the rules of the `__Secure-` and `__Host-` prefixes are applied so that browsers accept it
+func newForgottenCookie(name string) *http.Cookie {
+	cookie := &http.Cookie{
+		Name:    name,
+		Value:   "",
+		Expires: time.Unix(1, 0).UTC(),
+		MaxAge:  -1,
+	}
+	switch {
+	case strings.HasPrefix(name, hostCookiePrefix):
+		cookie.Secure = true
+		cookie.Path = "/"
+	case strings.HasPrefix(name, secureCookiePrefix):
+		cookie.Secure = true
+	}
+	return cookie
+}
@@ -0,0 +1,133 @@
+package response
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCookiePolicy_Apply(t *testing.T) {
+	t.Run("Defaults", func(t *testing.T) {
+		policy := &CookiePolicy{Secure: true, HttpOnly: true, SameSite: http.SameSiteLaxMode, Path: "/", Domain: "example.com"}
+		original := &http.Cookie{Name: "session", Value: "abc", Path: "/admin"}
+		cookies, err := policy.Apply([]*http.Cookie{original})
+		assert.Nil(t, err)
+		assert.Len(t, cookies, 1)
+		assert.True(t, cookies[0].Secure)
+		assert.True(t, cookies[0].HttpOnly)
+		assert.Equal(t, http.SameSiteLaxMode, cookies[0].SameSite)
+		assert.Equal(t, "/admin", cookies[0].Path)
+		assert.Equal(t, "example.com", cookies[0].Domain)
+		assert.False(t, original.Secure)
+	})
+
+	t.Run("HostPrefix", func(t *testing.T) {
+		policy := &CookiePolicy{Domain: "example.com", Path: "/app"}
+		cookies, err := policy.Apply([]*http.Cookie{{Name: "__Host-id", Value: "1"}})
+		assert.Nil(t, err)
+		assert.True(t, cookies[0].Secure)
+		assert.Equal(t, "/", cookies[0].Path)
+		assert.Equal(t, "", cookies[0].Domain)
+
+		_, err = policy.Apply([]*http.Cookie{{Name: "__Host-id", Value: "1", Domain: "example.com"}})
+		assert.NotNil(t, err)
+		_, err = policy.Apply([]*http.Cookie{{Name: "__Host-id", Value: "1", Path: "/app"}})
+		assert.NotNil(t, err)
+	})
+
+	t.Run("SecurePrefix", func(t *testing.T) {
+		policy := &CookiePolicy{}
+		cookies, err := policy.Apply([]*http.Cookie{{Name: "__Secure-id", Value: "1"}})
+		assert.Nil(t, err)
+		assert.True(t, cookies[0].Secure)
+	})
+
+	t.Run("SizeLimit", func(t *testing.T) {
+		policy := &CookiePolicy{}
+		_, err := policy.Apply([]*http.Cookie{{Name: "big", Value: strings.Repeat("a", MaxCookieSize)}})
+		assert.NotNil(t, err)
+		policy.MaxSize = 10
+		_, err = policy.Apply([]*http.Cookie{{Name: "small", Value: "0123456789"}})
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Dedupe", func(t *testing.T) {
+		policy := &CookiePolicy{}
+		cookies, err := policy.Apply([]*http.Cookie{
+			{Name: "a", Value: "1", Path: "/"},
+			{Name: "b", Value: "2", Path: "/"},
+			{Name: "a", Value: "3", Path: "/"},
+			{Name: "a", Value: "4", Path: "/sub"},
+		})
+		assert.Nil(t, err)
+		assert.Len(t, cookies, 3)
+		assert.Equal(t, "3", cookies[0].Value)
+		assert.Equal(t, "2", cookies[1].Value)
+		assert.Equal(t, "4", cookies[2].Value)
+	})
+}
+
+func TestResponse_ForgetCookie(t *testing.T) {
+	response := New(200)
+	response.SetCookie(&http.Cookie{Name: "session", Value: "abc", Path: "/"})
+	response.ForgetCookie("session").Path = "/"
+	response.SetCookiePolicy(&CookiePolicy{})
+
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest("GET", "/", nil)
+	response.ServeHTTP(recorder, request)
+
+	cookies := recorder.Result().Cookies()
+	assert.Len(t, cookies, 1)
+	assert.Equal(t, "session", cookies[0].Name)
+	assert.Equal(t, "", cookies[0].Value)
+	assert.Equal(t, -1, cookies[0].MaxAge)
+
+	response = New(200)
+	response.SetCookie(&http.Cookie{Name: "session", Value: "abc"})
+	response.ForgetCookie("session")
+
+	recorder = httptest.NewRecorder()
+	response.ServeHTTP(recorder, request)
+
+	cookies = recorder.Result().Cookies()
+	assert.Len(t, cookies, 1)
+	assert.Equal(t, -1, cookies[0].MaxAge)
+}
+
+func TestResponse_ForgetPrefixedCookie(t *testing.T) {
+	response := New(200)
+	response.ForgetCookie("__Host-session")
+	response.ForgetCookie("__Secure-theme").Path = "/app"
+
+	recorder := httptest.NewRecorder()
+	response.ServeHTTP(recorder, httptest.NewRequest("GET", "/", nil))
+
+	cookies := recorder.Result().Cookies()
+	if assert.Len(t, cookies, 2) {
+		assert.True(t, cookies[0].Secure)
+		assert.Equal(t, "/", cookies[0].Path)
+		assert.Empty(t, cookies[0].Domain)
+		assert.True(t, cookies[1].Secure)
+		assert.Equal(t, "/app", cookies[1].Path)
+	}
+}
+
+func TestResponse_DefaultCookiePolicy(t *testing.T) {
+	SetDefaultCookiePolicy(&CookiePolicy{HttpOnly: true})
+	defer SetDefaultCookiePolicy(nil)
+
+	response := New(302).Redirect("/")
+	response.SetCookie(&http.Cookie{Name: "flash", Value: "saved"})
+
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest("GET", "/", nil)
+	response.ServeHTTP(recorder, request)
+
+	cookies := recorder.Result().Cookies()
+	assert.Len(t, cookies, 1)
+	assert.True(t, cookies[0].HttpOnly)
+}
@@ -0,0 +1,165 @@
+package response
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var defaultCORSPolicy *CORSPolicy
+
+// denyCORSPolicy allows no origin, it overrides the default policy on rejected preflight responses
+var denyCORSPolicy = &CORSPolicy{}
+
+// SetDefaultCORSPolicy sets the CORS policy applied to responses
+// which have no policy of their own, pass nil to disable it.
+func SetDefaultCORSPolicy(policy *CORSPolicy) {
+	defaultCORSPolicy = policy
+}
+
+// DefaultCORSPolicy returns the global CORS policy
+func DefaultCORSPolicy() *CORSPolicy {
+	return defaultCORSPolicy
+}
+
+// CORSPolicy describes which cross-origin requests are allowed.
+//   - AllowedOrigins: The allowed origins, an entry can be "*" to allow any origin,
+//     or contain a single "*" to allow subdomains, e.g. "https://*.example.com".
+//   - AllowOriginFunc: A matcher used when the origin is not in AllowedOrigins.
+//   - AllowedMethods: The methods allowed in preflight responses, GET, HEAD and POST are used when it is empty.
+//   - AllowedHeaders: The request headers allowed in preflight responses, "*" allows any requested header.
+//   - ExposedHeaders: The response headers readable by the client.
+//   - AllowCredentials: Allows cookies and authorization headers.
+//   - MaxAge: How long the preflight result can be cached.
+//
+// When AllowCredentials is true, wildcard entries never match and
+// the request origin is always sent instead of "*".
+type CORSPolicy struct {
+	AllowedOrigins   []string
+	AllowOriginFunc  func(origin string) bool
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	ExposedHeaders   []string
+	AllowCredentials bool
+	MaxAge           time.Duration
+}
+
+// IsPreflight returns if the request is a CORS preflight request
+func IsPreflight(r *http.Request) bool {
+	return r.Method == http.MethodOptions &&
+		r.Header.Get("Origin") != "" &&
+		r.Header.Get("Access-Control-Request-Method") != ""
+}
+
+// AllowsOrigin returns if the origin is allowed by the policy
+func (policy *CORSPolicy) AllowsOrigin(origin string) bool {
+	if origin == "" {
+		return false
+	}
+	for _, allowed := range policy.AllowedOrigins {
+		if allowed == "*" {
+			if !policy.AllowCredentials {
+				return true
+			}
+			continue
+		}
+		if strings.Contains(allowed, "*") {
+			if policy.AllowCredentials {
+				continue
+			}
+			prefix, suffix, _ := strings.Cut(strings.ToLower(allowed), "*")
+			lower := strings.ToLower(origin)
+			if len(lower) > len(prefix)+len(suffix) && strings.HasPrefix(lower, prefix) && strings.HasSuffix(lower, suffix) {
+				return true
+			}
+			continue
+		}
+		if strings.EqualFold(allowed, origin) {
+			return true
+		}
+	}
+	if policy.AllowOriginFunc != nil {
+		return policy.AllowOriginFunc(origin)
+	}
+	return false
+}
+
+// Apply adds the CORS headers of the request origin to header
+func (policy *CORSPolicy) Apply(header http.Header, r *http.Request) {
+	if policy.allowsAnyOrigin() {
+		header.Set("Access-Control-Allow-Origin", "*")
+	} else {
+		appendVary(header, "Origin")
+		origin := r.Header.Get("Origin")
+		if !policy.AllowsOrigin(origin) {
+			header.Del("Access-Control-Allow-Origin")
+			return
+		}
+		header.Set("Access-Control-Allow-Origin", origin)
+	}
+	if policy.AllowCredentials {
+		header.Set("Access-Control-Allow-Credentials", "true")
+	}
+	if len(policy.ExposedHeaders) > 0 && !IsPreflight(r) {
+		header.Set("Access-Control-Expose-Headers", strings.Join(policy.ExposedHeaders, ", "))
+	}
+}
+
+// Preflight returns the 204 response of a preflight request, if the origin or the requested method
+// is not allowed, the response carries no CORS headers and the browser rejects the actual request.
+func (policy *CORSPolicy) Preflight(r *http.Request) *Response {
+	response := New(http.StatusNoContent)
+	response.SetCORSPolicy(denyCORSPolicy)
+	response.SetHeader("Vary", "Access-Control-Request-Method", false)
+	response.SetHeader("Vary", "Access-Control-Request-Headers", false)
+	if !policy.AllowsOrigin(r.Header.Get("Origin")) {
+		return response
+	}
+	method := r.Header.Get("Access-Control-Request-Method")
+	methods := policy.AllowedMethods
+	if len(methods) == 0 {
+		methods = []string{http.MethodGet, http.MethodHead, http.MethodPost}
+	}
+	if !slices.ContainsFunc(methods, func(m string) bool { return strings.EqualFold(m, method) }) {
+		return response
+	}
+	response.SetCORSPolicy(policy)
+	response.SetHeader("Access-Control-Allow-Methods", strings.Join(methods, ", "))
+	if requested := r.Header.Get("Access-Control-Request-Headers"); requested != "" {
+		if slices.Contains(policy.AllowedHeaders, "*") {
+			response.SetHeader("Access-Control-Allow-Headers", requested)
+		} else if len(policy.AllowedHeaders) > 0 {
+			response.SetHeader("Access-Control-Allow-Headers", strings.Join(policy.AllowedHeaders, ", "))
+		}
+	}
+	if policy.MaxAge > 0 {
+		response.SetHeader("Access-Control-Max-Age", strconv.FormatInt(int64(policy.MaxAge/time.Second), 10))
+	}
+	return response
+}
+
+func (policy *CORSPolicy) allowsAnyOrigin() bool {
+	return !policy.AllowCredentials && policy.AllowOriginFunc == nil && slices.Contains(policy.AllowedOrigins, "*")
+}
+
+// appendVary adds the header names to the Vary header unless they are already listed
+func appendVary(header http.Header, names ...string) {
+	existing := make(map[string]bool)
+	for _, value := range header.Values("Vary") {
+		for _, name := range strings.Split(value, ",") {
+			existing[strings.ToLower(strings.TrimSpace(name))] = true
+		}
+	}
+	if existing["*"] {
+		return
+	}
+	for _, name := range names {
+		if existing[strings.ToLower(name)] {
+			continue
+		}
+		existing[strings.ToLower(name)] = true
+		header.Add("Vary", name)
+	}
+}
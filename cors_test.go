@@ -0,0 +1,118 @@
+package response
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCORSPolicy_AllowsOrigin(t *testing.T) {
+	policy := &CORSPolicy{AllowedOrigins: []string{"https://example.com", "https://*.example.org"}}
+	assert.True(t, policy.AllowsOrigin("https://example.com"))
+	assert.True(t, policy.AllowsOrigin("https://EXAMPLE.com"))
+	assert.True(t, policy.AllowsOrigin("https://api.example.org"))
+	assert.False(t, policy.AllowsOrigin("https://example.org"))
+	assert.False(t, policy.AllowsOrigin("https://evil.com"))
+	assert.False(t, policy.AllowsOrigin(""))
+
+	policy.AllowOriginFunc = func(origin string) bool { return origin == "https://partner.com" }
+	assert.True(t, policy.AllowsOrigin("https://partner.com"))
+
+	credentials := &CORSPolicy{AllowedOrigins: []string{"*", "https://*.example.org"}, AllowCredentials: true}
+	assert.False(t, credentials.AllowsOrigin("https://evil.com"))
+	assert.False(t, credentials.AllowsOrigin("https://api.example.org"))
+}
+
+func TestResponse_CORS(t *testing.T) {
+	t.Run("AnyOrigin", func(t *testing.T) {
+		response := New(200, "ok")
+		response.SetCORSPolicy(&CORSPolicy{AllowedOrigins: []string{"*"}, ExposedHeaders: []string{"X-Request-Id"}})
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest("GET", "/", nil)
+		request.Header.Set("Origin", "https://example.com")
+		response.ServeHTTP(recorder, request)
+
+		result := recorder.Result()
+		assert.Equal(t, "*", result.Header.Get("Access-Control-Allow-Origin"))
+		assert.Equal(t, "X-Request-Id", result.Header.Get("Access-Control-Expose-Headers"))
+		assert.Empty(t, result.Header.Values("Vary"))
+	})
+
+	t.Run("Credentials", func(t *testing.T) {
+		response := New(200).JSON(map[string]string{"message": "ok"})
+		response.SetHeader("Vary", "Accept-Encoding")
+		response.SetCORSPolicy(&CORSPolicy{AllowedOrigins: []string{"*", "https://example.com"}, AllowCredentials: true})
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest("GET", "/", nil)
+		request.Header.Set("Origin", "https://example.com")
+		response.ServeHTTP(recorder, request)
+
+		result := recorder.Result()
+		assert.Equal(t, "https://example.com", result.Header.Get("Access-Control-Allow-Origin"))
+		assert.Equal(t, "true", result.Header.Get("Access-Control-Allow-Credentials"))
+		assert.Equal(t, []string{"Accept-Encoding", "Origin"}, result.Header.Values("Vary"))
+	})
+
+	t.Run("DisallowedOrigin", func(t *testing.T) {
+		response := New(200, "ok")
+		response.SetCORSPolicy(&CORSPolicy{AllowedOrigins: []string{"https://example.com"}})
+		response.SetHeader("Access-Control-Allow-Origin", "*")
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest("GET", "/", nil)
+		request.Header.Set("Origin", "https://evil.com")
+		response.ServeHTTP(recorder, request)
+
+		result := recorder.Result()
+		assert.Equal(t, "", result.Header.Get("Access-Control-Allow-Origin"))
+		assert.Equal(t, "Origin", result.Header.Get("Vary"))
+	})
+}
+
+func TestCORSPolicy_Preflight(t *testing.T) {
+	policy := &CORSPolicy{
+		AllowedOrigins: []string{"https://example.com"},
+		AllowedMethods: []string{"GET", "PUT"},
+		AllowedHeaders: []string{"*"},
+		ExposedHeaders: []string{"X-Request-Id"},
+		MaxAge:         10 * time.Minute,
+	}
+	newRequest := func(origin, method string) *http.Request {
+		request := httptest.NewRequest("OPTIONS", "/", nil)
+		request.Header.Set("Origin", origin)
+		request.Header.Set("Access-Control-Request-Method", method)
+		request.Header.Set("Access-Control-Request-Headers", "content-type, x-token")
+		return request
+	}
+
+	t.Run("Allowed", func(t *testing.T) {
+		request := newRequest("https://example.com", "PUT")
+		assert.True(t, IsPreflight(request))
+		recorder := httptest.NewRecorder()
+		policy.Preflight(request).ServeHTTP(recorder, request)
+
+		result := recorder.Result()
+		assert.Equal(t, http.StatusNoContent, result.StatusCode)
+		assert.Equal(t, "https://example.com", result.Header.Get("Access-Control-Allow-Origin"))
+		assert.Equal(t, "GET, PUT", result.Header.Get("Access-Control-Allow-Methods"))
+		assert.Equal(t, "content-type, x-token", result.Header.Get("Access-Control-Allow-Headers"))
+		assert.Equal(t, "600", result.Header.Get("Access-Control-Max-Age"))
+		assert.Equal(t, "", result.Header.Get("Access-Control-Expose-Headers"))
+		assert.Equal(t, []string{"Access-Control-Request-Method", "Access-Control-Request-Headers", "Origin"}, result.Header.Values("Vary"))
+	})
+
+	t.Run("DisallowedMethod", func(t *testing.T) {
+		SetDefaultCORSPolicy(&CORSPolicy{AllowedOrigins: []string{"*"}})
+		defer SetDefaultCORSPolicy(nil)
+		request := newRequest("https://example.com", "DELETE")
+		recorder := httptest.NewRecorder()
+		policy.Preflight(request).ServeHTTP(recorder, request)
+
+		result := recorder.Result()
+		assert.Equal(t, http.StatusNoContent, result.StatusCode)
+		assert.Equal(t, "", result.Header.Get("Access-Control-Allow-Origin"))
+		assert.Equal(t, "", result.Header.Get("Access-Control-Allow-Methods"))
+	})
+}
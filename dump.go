@@ -0,0 +1,170 @@
+package response
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Redacted replaces the redacted header and cookie values in dumps
+const Redacted = "[REDACTED]"
+
+// DumpOptions changes how a response is dumped by [Dump].
+//   - MaxBodySize: The number of body bytes kept in the dump, zero keeps the whole body and a negative size drops it.
+//     The framing headers of a truncated body are not changed, so the dump shows the original length.
+//   - RedactHeaders: The headers whose values are replaced with [Redacted], e.g. "Set-Cookie".
+//   - RedactCookies: The cookies whose values are replaced with [Redacted], "*" redacts all cookies.
+type DumpOptions struct {
+	MaxBodySize   int
+	RedactHeaders []string
+	RedactCookies []string
+}
+
+// Dump renders a response of any type for the request and returns it in the HTTP/1.1 wire format:
+// the status line, the headers sorted by name with one Set-Cookie line per cookie, and the body.
+// As with [net/http], a missing Content-Type is sniffed from the body and the length is sent as Content-Length,
+// unless the handler flushed while writing, in which case the body is framed in chunks split at every flush.
+// The Date header is left out to keep dumps reproducible.
+func Dump(response http.Handler, r *http.Request, options ...DumpOptions) []byte {
+	var option DumpOptions
+	if len(options) > 0 {
+		option = options[0]
+	}
+	writer := &dumpResponseWriter{bufferedResponseWriter: newBufferedResponseWriter()}
+	response.ServeHTTP(writer, r)
+	header := writer.header.Clone()
+	body := writer.body.Bytes()
+	statusCode := writer.statusCode
+
+	bodyAllowed := bodyAllowedForStatus(statusCode)
+	chunked := bodyAllowed && header.Get("Content-Length") == "" &&
+		(writer.flushed || slices.Contains(header.Values("Transfer-Encoding"), "chunked"))
+	if bodyAllowed {
+		if header.Get("Content-Type") == "" && len(body) > 0 {
+			header.Set("Content-Type", http.DetectContentType(body))
+		}
+		if chunked {
+			header.Set("Transfer-Encoding", "chunked")
+		} else if header.Get("Content-Length") == "" {
+			header.Set("Content-Length", strconv.Itoa(len(body)))
+		}
+	} else {
+		header.Del("Content-Length")
+		header.Del("Transfer-Encoding")
+		body = nil
+	}
+	if r.Method == http.MethodHead {
+		body = nil
+	}
+	redactDumpHeader(header, option)
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "HTTP/1.1 %03d %s\r\n", statusCode, http.StatusText(statusCode))
+	_ = header.Write(&buf)
+	buf.WriteString("\r\n")
+	if len(body) == 0 && !chunked || r.Method == http.MethodHead {
+		return buf.Bytes()
+	}
+	limit := len(body)
+	if option.MaxBodySize < 0 {
+		limit = 0
+	} else if option.MaxBodySize > 0 && option.MaxBodySize < limit {
+		limit = option.MaxBodySize
+	}
+	if !chunked {
+		buf.Write(body[:limit])
+		return buf.Bytes()
+	}
+	start := 0
+	for _, end := range append(writer.chunks, len(body)) {
+		if end > limit {
+			end = limit
+		}
+		if end > start {
+			fmt.Fprintf(&buf, "%x\r\n", end-start)
+			buf.Write(body[start:end])
+			buf.WriteString("\r\n")
+			start = end
+		}
+	}
+	if limit == len(body) {
+		buf.WriteString("0\r\n\r\n")
+	}
+	return buf.Bytes()
+}
+
+// ParseDump parses a response in the HTTP/1.1 wire format, such as the output of [Dump], into a [Response].
+// The cookies are parsed from the Set-Cookie headers and the body becomes the content,
+// a truncated body is kept as it is.
+func ParseDump(dump []byte) (*Response, error) {
+	resp, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(dump)), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, err
+	}
+	response := New(resp.StatusCode)
+	for key, values := range resp.Header {
+		if key == "Set-Cookie" || key == "Content-Length" {
+			continue
+		}
+		response.headers[key] = values
+	}
+	for _, key := range hopByHopHeaders {
+		response.headers.Del(key)
+	}
+	for _, cookie := range resp.Cookies() {
+		response.SetCookie(cookie)
+	}
+	if len(body) > 0 {
+		response.SetContent(body)
+	}
+	return response, nil
+}
+
+func redactDumpHeader(header http.Header, option DumpOptions) {
+	for _, key := range option.RedactHeaders {
+		values := header.Values(key)
+		for i := range values {
+			values[i] = Redacted
+		}
+	}
+	if len(option.RedactCookies) == 0 {
+		return
+	}
+	cookies := header.Values("Set-Cookie")
+	for i, cookie := range cookies {
+		pair, attributes, _ := strings.Cut(cookie, ";")
+		name, _, ok := strings.Cut(pair, "=")
+		if !ok || !slices.Contains(option.RedactCookies, "*") && !slices.Contains(option.RedactCookies, strings.TrimSpace(name)) {
+			continue
+		}
+		cookies[i] = name + "=" + Redacted
+		if attributes != "" {
+			cookies[i] += ";" + attributes
+		}
+	}
+}
+
+// dumpResponseWriter keeps a rendered response in memory with the positions the body was flushed at
+type dumpResponseWriter struct {
+	*bufferedResponseWriter
+	chunks  []int
+	flushed bool
+}
+
+func (writer *dumpResponseWriter) Flush() {
+	writer.flushed = true
+	if end := writer.body.Len(); end > 0 && (len(writer.chunks) == 0 || writer.chunks[len(writer.chunks)-1] < end) {
+		writer.chunks = append(writer.chunks, end)
+	}
+}
@@ -0,0 +1,124 @@
+package response
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDump(t *testing.T) {
+	t.Run("content length", func(t *testing.T) {
+		response := New(http.StatusCreated, "hello")
+		response.SetHeader("Content-Type", "text/plain")
+		response.SetHeader("X-Request-Id", "42")
+		response.SetCookie(&http.Cookie{Name: "session", Value: "abc", Path: "/"})
+		dump := Dump(response, httptest.NewRequest("GET", "/", nil))
+		assert.Equal(t, "HTTP/1.1 201 Created\r\n"+
+			"Content-Length: 5\r\n"+
+			"Content-Type: text/plain\r\n"+
+			"Set-Cookie: session=abc; Path=/\r\n"+
+			"X-Request-Id: 42\r\n"+
+			"\r\n"+
+			"hello", string(dump))
+	})
+
+	t.Run("chunked", func(t *testing.T) {
+		parts := []string{"first", "second"}
+		response := New(http.StatusOK).Stream(func(w io.Writer) bool {
+			if len(parts) == 0 {
+				return false
+			}
+			_, _ = w.Write([]byte(parts[0]))
+			parts = parts[1:]
+			return true
+		})
+		response.SetHeader("Content-Type", "text/plain")
+		dump := Dump(response, httptest.NewRequest("GET", "/", nil))
+		assert.Equal(t, "HTTP/1.1 200 OK\r\n"+
+			"Content-Type: text/plain\r\n"+
+			"Transfer-Encoding: chunked\r\n"+
+			"\r\n"+
+			"b\r\nfirstsecond\r\n"+
+			"0\r\n\r\n", string(dump))
+	})
+
+	t.Run("no content", func(t *testing.T) {
+		dump := Dump(New(http.StatusNoContent), httptest.NewRequest("DELETE", "/", nil))
+		assert.Equal(t, "HTTP/1.1 204 No Content\r\n\r\n", string(dump))
+	})
+
+	t.Run("head", func(t *testing.T) {
+		dump := Dump(New(http.StatusOK, "hello"), httptest.NewRequest("HEAD", "/", nil))
+		assert.Equal(t, "HTTP/1.1 200 OK\r\n"+
+			"Content-Length: 5\r\n"+
+			"Content-Type: text/plain; charset=utf-8\r\n"+
+			"\r\n", string(dump))
+	})
+
+	t.Run("truncate and redact", func(t *testing.T) {
+		response := New(http.StatusOK, "0123456789")
+		response.SetHeader("Content-Type", "text/plain")
+		response.SetHeader("X-Api-Key", "secret")
+		response.SetCookie(&http.Cookie{Name: "session", Value: "abc", HttpOnly: true})
+		response.SetCookie(&http.Cookie{Name: "theme", Value: "dark"})
+		dump := Dump(response, httptest.NewRequest("GET", "/", nil), DumpOptions{
+			MaxBodySize:   4,
+			RedactHeaders: []string{"x-api-key"},
+			RedactCookies: []string{"session"},
+		})
+		assert.Equal(t, "HTTP/1.1 200 OK\r\n"+
+			"Content-Length: 10\r\n"+
+			"Content-Type: text/plain\r\n"+
+			"Set-Cookie: session=[REDACTED]; HttpOnly\r\n"+
+			"Set-Cookie: theme=dark\r\n"+
+			"X-Api-Key: [REDACTED]\r\n"+
+			"\r\n"+
+			"0123", string(dump))
+	})
+
+	t.Run("drop body", func(t *testing.T) {
+		dump := Dump(New(http.StatusOK, "hello"), httptest.NewRequest("GET", "/", nil), DumpOptions{MaxBodySize: -1})
+		assert.True(t, strings.HasSuffix(string(dump), "Content-Length: 5\r\nContent-Type: text/plain; charset=utf-8\r\n\r\n"))
+	})
+}
+
+func TestParseDump(t *testing.T) {
+	t.Run("round trip", func(t *testing.T) {
+		original := New(http.StatusAccepted).JSON(map[string]string{"status": "queued"})
+		original.SetHeader("X-Request-Id", "42")
+		original.SetCookie(&http.Cookie{Name: "session", Value: "abc", Path: "/"})
+		request := httptest.NewRequest("GET", "/", nil)
+
+		response, err := ParseDump(Dump(original, request))
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusAccepted, response.StatusCode())
+		assert.Equal(t, "42", response.Header("X-Request-Id"))
+		assert.False(t, response.HasHeader("Content-Length"))
+		if assert.Len(t, response.Cookies(), 1) {
+			assert.Equal(t, "abc", response.Cookies()[0].Value)
+		}
+		assert.Equal(t, Dump(original, request), Dump(response, request))
+	})
+
+	t.Run("chunked", func(t *testing.T) {
+		response, err := ParseDump([]byte("HTTP/1.1 200 OK\r\nTransfer-Encoding: chunked\r\n\r\n5\r\nfirst\r\n6\r\nsecond\r\n0\r\n\r\n"))
+		assert.Nil(t, err)
+		assert.Equal(t, []byte("firstsecond"), response.Content())
+		assert.False(t, response.HasHeader("Transfer-Encoding"))
+	})
+
+	t.Run("truncated", func(t *testing.T) {
+		response, err := ParseDump([]byte("HTTP/1.1 200 OK\r\nContent-Length: 10\r\n\r\n0123"))
+		assert.Nil(t, err)
+		assert.Equal(t, []byte("0123"), response.Content())
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := ParseDump([]byte("not a response"))
+		assert.NotNil(t, err)
+	})
+}
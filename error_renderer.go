@@ -0,0 +1,213 @@
+package response
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"html/template"
+	"net/http"
+)
+
+var defaultErrorRenderer ErrorRenderer = NegotiatedErrorRenderer
+
+// SetDefaultErrorRenderer sets the error renderer of handlers which have no renderer of their own
+func SetDefaultErrorRenderer(renderer ErrorRenderer) {
+	defaultErrorRenderer = renderer
+}
+
+// DefaultErrorRenderer returns the global error renderer
+func DefaultErrorRenderer() ErrorRenderer {
+	return defaultErrorRenderer
+}
+
+// HTTPError is an error with the status code and the message sent to the client,
+// Err is the underlying error which is not shown to the client.
+type HTTPError struct {
+	StatusCode int
+	Message    string
+	Err        error
+}
+
+// NewHTTPError creates a new [HTTPError], the message defaults to the status text
+func NewHTTPError(statusCode int, message string, err ...error) *HTTPError {
+	if message == "" {
+		message = http.StatusText(statusCode)
+	}
+	httpError := &HTTPError{StatusCode: statusCode, Message: message}
+	if len(err) > 0 {
+		httpError.Err = err[0]
+	}
+	return httpError
+}
+
+// Error returns the message and the underlying error
+func (httpError *HTTPError) Error() string {
+	if httpError.Err != nil {
+		return httpError.Message + ": " + httpError.Err.Error()
+	}
+	return httpError.Message
+}
+
+// Unwrap returns the underlying error
+func (httpError *HTTPError) Unwrap() error {
+	return httpError.Err
+}
+
+// AsHTTPError converts err to an [HTTPError]. An error which has a StatusCode() int method keeps its status,
+// and its message when the status is a client error or the error has an ExposeMessage() bool method returning true,
+// the message of other server errors is the status text.
+// Other errors become an internal server error whose message is the status text.
+// When debug is true, the error message is always sent.
+func AsHTTPError(err error, debug bool) *HTTPError {
+	var httpError *HTTPError
+	if errors.As(err, &httpError) {
+		return httpError
+	}
+	var statusError interface{ StatusCode() int }
+	if errors.As(err, &statusError) {
+		statusCode := statusError.StatusCode()
+		var exposer interface{ ExposeMessage() bool }
+		if debug || statusCode < http.StatusInternalServerError || (errors.As(err, &exposer) && exposer.ExposeMessage()) {
+			return NewHTTPError(statusCode, err.Error(), err)
+		}
+		return NewHTTPError(statusCode, "", err)
+	}
+	if debug {
+		return NewHTTPError(http.StatusInternalServerError, err.Error(), err)
+	}
+	return NewHTTPError(http.StatusInternalServerError, "", err)
+}
+
+// ErrorRenderer returns the response which reports the error to the client
+type ErrorRenderer func(r *http.Request, err *HTTPError) http.Handler
+
+// PlainErrorRenderer renders the error message as plain text
+func PlainErrorRenderer(_ *http.Request, err *HTTPError) http.Handler {
+	response := New(err.StatusCode, err.Message)
+	response.SetHeader("Content-Type", "text/plain; charset=utf-8")
+	return response
+}
+
+// JSONErrorRenderer renders the error as a JSON object with the status and the message
+func JSONErrorRenderer(_ *http.Request, err *HTTPError) http.Handler {
+	return New(err.StatusCode).JSON(map[string]any{
+		"status":  err.StatusCode,
+		"message": err.Message,
+	})
+}
+
+// ProblemErrorRenderer renders the error as a RFC 9457 problem details object
+func ProblemErrorRenderer(r *http.Request, err *HTTPError) http.Handler {
+	problem := NewProblemDetails(err.StatusCode, err.Message)
+	problem.Instance = r.URL.Path
+	return problem.Response()
+}
+
+var defaultErrorPage = template.Must(template.New("error").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.StatusCode}} {{.Title}}</title>
+</head>
+<body>
+<h1>{{.StatusCode}} {{.Title}}</h1>
+{{if ne .Message .Title}}<p>{{.Message}}</p>{{end}}
+</body>
+</html>
+`))
+
+// HTMLErrorRenderer returns a renderer which executes page with the StatusCode, Title and Message of the error,
+// a minimal page is used when page is nil
+func HTMLErrorRenderer(page *template.Template) ErrorRenderer {
+	if page == nil {
+		page = defaultErrorPage
+	}
+	return func(_ *http.Request, err *HTTPError) http.Handler {
+		buf := new(bytes.Buffer)
+		if execErr := page.Execute(buf, map[string]any{
+			"StatusCode": err.StatusCode,
+			"Title":      http.StatusText(err.StatusCode),
+			"Message":    err.Message,
+		}); execErr != nil {
+			panic(execErr)
+		}
+		response := New(err.StatusCode, buf.String())
+		response.SetHeader("Content-Type", "text/html; charset=utf-8")
+		return response
+	}
+}
+
+// NegotiatedErrorRenderer picks a renderer from the Accept header of the request:
+// problem details, JSON, HTML or plain text. The listed format with the highest quality is used, the first of these on a tie,
+// and plain text when none is listed, e.g. for */*.
+func NegotiatedErrorRenderer(r *http.Request, err *HTTPError) http.Handler {
+	ranges := parseAccept(r.Header.Get("Accept"))
+	switch negotiateMediaType(ranges, isMediaType("application/problem+json"), isJSONMediaType, isMediaType("text/html"), isMediaType("text/plain")) {
+	case 0:
+		return ProblemErrorRenderer(r, err)
+	case 1:
+		return JSONErrorRenderer(r, err)
+	case 2:
+		return HTMLErrorRenderer(nil)(r, err)
+	}
+	return PlainErrorRenderer(r, err)
+}
+
+// ProblemDetails is a RFC 9457 problem details object, Extensions are added as members of the object
+type ProblemDetails struct {
+	Type       string
+	Title      string
+	Status     int
+	Detail     string
+	Instance   string
+	Extensions map[string]any
+}
+
+// NewProblemDetails creates a new [ProblemDetails] titled with the status text,
+// the detail is left out when it repeats the title
+func NewProblemDetails(statusCode int, detail string) *ProblemDetails {
+	problem := &ProblemDetails{
+		Type:   "about:blank",
+		Title:  http.StatusText(statusCode),
+		Status: statusCode,
+	}
+	if detail != problem.Title {
+		problem.Detail = detail
+	}
+	return problem
+}
+
+// MarshalJSON encodes the problem with its extensions, the standard members take precedence
+func (problem *ProblemDetails) MarshalJSON() ([]byte, error) {
+	members := make(map[string]any, len(problem.Extensions)+5)
+	for key, value := range problem.Extensions {
+		members[key] = value
+	}
+	if problem.Type != "" {
+		members["type"] = problem.Type
+	}
+	if problem.Title != "" {
+		members["title"] = problem.Title
+	}
+	if problem.Status != 0 {
+		members["status"] = problem.Status
+	}
+	if problem.Detail != "" {
+		members["detail"] = problem.Detail
+	}
+	if problem.Instance != "" {
+		members["instance"] = problem.Instance
+	}
+	return json.Marshal(members)
+}
+
+// Response returns a JSON response of the problem with the application/problem+json content type
+func (problem *ProblemDetails) Response() *JSONResponse {
+	statusCode := problem.Status
+	if statusCode == 0 {
+		statusCode = http.StatusInternalServerError
+	}
+	response := New(statusCode).JSON(problem)
+	response.SetHeader("Content-Type", "application/problem+json")
+	return response
+}
@@ -0,0 +1,96 @@
+package response
+
+import (
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func renderError(renderer ErrorRenderer, request *http.Request, err *HTTPError) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	renderer(request, err).ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestNegotiatedErrorRenderer(t *testing.T) {
+	err := NewHTTPError(http.StatusNotFound, "user not found")
+	cases := []struct {
+		accept      string
+		contentType string
+	}{
+		{"application/problem+json", "application/problem+json"},
+		{"application/json", "application/json"},
+		{"text/html,application/xhtml+xml", "text/html; charset=utf-8"},
+		{"", "text/plain; charset=utf-8"},
+		{"*/*", "text/plain; charset=utf-8"},
+		{"application/hal+json", "application/json"},
+		{"text/html, application/json;q=0", "text/html; charset=utf-8"},
+		{"text/html;q=0.5, application/json", "application/json"},
+		{"application/json;q=0.5, text/plain", "text/plain; charset=utf-8"},
+		{"application/json, application/problem+json", "application/problem+json"},
+		{"text/x-json-foo", "text/plain; charset=utf-8"},
+	}
+	for _, c := range cases {
+		request := httptest.NewRequest("GET", "/users/1", nil)
+		request.Header.Set("Accept", c.accept)
+		recorder := renderError(NegotiatedErrorRenderer, request, err)
+		assert.Equal(t, http.StatusNotFound, recorder.Code, c.accept)
+		assert.Equal(t, c.contentType, recorder.Header().Get("Content-Type"), c.accept)
+		assert.Contains(t, recorder.Body.String(), "user not found", c.accept)
+	}
+}
+
+func TestHTMLErrorRenderer(t *testing.T) {
+	request := httptest.NewRequest("GET", "/", nil)
+	recorder := renderError(HTMLErrorRenderer(nil), request, NewHTTPError(http.StatusForbidden, "<no access>"))
+	assert.Contains(t, recorder.Body.String(), "<h1>403 Forbidden</h1>")
+	assert.Contains(t, recorder.Body.String(), "<p>&lt;no access&gt;</p>")
+
+	page := template.Must(template.New("page").Parse(`{{.Title}}: {{.Message}}`))
+	recorder = renderError(HTMLErrorRenderer(page), request, NewHTTPError(http.StatusForbidden, ""))
+	assert.Equal(t, "Forbidden: Forbidden", recorder.Body.String())
+}
+
+func TestProblemDetails(t *testing.T) {
+	problem := NewProblemDetails(http.StatusConflict, "email is taken")
+	problem.Extensions = map[string]any{"field": "email", "status": "ignored"}
+	recorder := httptest.NewRecorder()
+	problem.Response().ServeHTTP(recorder, httptest.NewRequest("POST", "/users", nil))
+	assert.Equal(t, http.StatusConflict, recorder.Code)
+	assert.Equal(t, "application/problem+json", recorder.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{"type":"about:blank","title":"Conflict","status":409,"detail":"email is taken","field":"email"}`, recorder.Body.String())
+}
+
+func TestAsHTTPError(t *testing.T) {
+	httpError := NewHTTPError(http.StatusBadRequest, "")
+	assert.Same(t, httpError, AsHTTPError(httpError, false))
+	assert.Equal(t, "Bad Request", httpError.Message)
+
+	err := AsHTTPError(statusError{}, false)
+	assert.Equal(t, http.StatusTooManyRequests, err.StatusCode)
+	assert.Equal(t, "quota exceeded", err.Message)
+
+	err = AsHTTPError(serverStatusError{}, false)
+	assert.Equal(t, http.StatusServiceUnavailable, err.StatusCode)
+	assert.Equal(t, "Service Unavailable", err.Message)
+	assert.Equal(t, "dial tcp 10.0.0.5:5432: connection refused", AsHTTPError(serverStatusError{}, true).Message)
+	assert.Equal(t, "maintenance until 10:00", AsHTTPError(serverStatusError{expose: true}, false).Message)
+}
+
+type serverStatusError struct {
+	expose bool
+}
+
+func (err serverStatusError) Error() string {
+	if err.expose {
+		return "maintenance until 10:00"
+	}
+	return "dial tcp 10.0.0.5:5432: connection refused"
+}
+
+func (serverStatusError) StatusCode() int { return http.StatusServiceUnavailable }
+
+func (err serverStatusError) ExposeMessage() bool { return err.expose }
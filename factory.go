@@ -0,0 +1,188 @@
+package response
+
+import (
+	"io"
+	"net/http"
+)
+
+// Factory creates responses which share the configuration of an application:
+// default headers, the charset of text content types, JSON and XML encoding options, the template engine of views,
+// the cookie policy and the error renderer of handlers. Named response builders can be registered as macros.
+// A factory is configured once at startup and passed to the code creating responses,
+// so tests can create their own factory with a different configuration.
+type Factory struct {
+	headers       http.Header
+	charset       string
+	jsonOptions   JSONOptions
+	xmlOptions    XMLOptions
+	engine        TemplateEngine
+	cookiePolicy  *CookiePolicy
+	errorRenderer ErrorRenderer
+	debug         bool
+	macros        *macroRegistry
+}
+
+// NewFactory creates a new [Factory] using the utf-8 charset
+func NewFactory() *Factory {
+	return &Factory{
+		headers: make(http.Header),
+		charset: "utf-8",
+		macros:  newMacroRegistry(),
+	}
+}
+
+// SetHeader sets a default header, it is sent unless a response sets the header itself
+func (factory *Factory) SetHeader(key, value string) *Factory {
+	factory.headers.Set(key, value)
+	return factory
+}
+
+// SetCharset sets the charset of the text content types set by the factory, an empty charset leaves it out
+func (factory *Factory) SetCharset(charset string) *Factory {
+	factory.charset = charset
+	return factory
+}
+
+// SetJSONOptions sets the encoding options of JSON responses
+func (factory *Factory) SetJSONOptions(options JSONOptions) *Factory {
+	factory.jsonOptions = options
+	return factory
+}
+
+// SetXMLOptions sets the encoding options of XML responses
+func (factory *Factory) SetXMLOptions(options XMLOptions) *Factory {
+	factory.xmlOptions = options
+	return factory
+}
+
+// SetTemplateEngine sets the template engine of views
+func (factory *Factory) SetTemplateEngine(engine TemplateEngine) *Factory {
+	factory.engine = engine
+	return factory
+}
+
+// SetCookiePolicy sets the cookie policy of responses, it overrides the default cookie policy
+func (factory *Factory) SetCookiePolicy(policy *CookiePolicy) *Factory {
+	factory.cookiePolicy = policy
+	return factory
+}
+
+// SetErrorRenderer sets the error renderer of handlers and errors, it overrides the default error renderer
+func (factory *Factory) SetErrorRenderer(renderer ErrorRenderer) *Factory {
+	factory.errorRenderer = renderer
+	return factory
+}
+
+// SetDebug sets if the messages of internal errors are shown to the client
+func (factory *Factory) SetDebug(debug bool) *Factory {
+	factory.debug = debug
+	return factory
+}
+
+// New creates a new [Response] with the configuration of the factory
+func (factory *Factory) New(statusCode int, content ...any) *Response {
+	response := New(statusCode, content...)
+	for key, values := range factory.headers {
+		response.headers[key] = append([]string(nil), values...)
+	}
+	if factory.cookiePolicy != nil {
+		response.SetCookiePolicy(factory.cookiePolicy)
+	}
+	return response
+}
+
+// Text returns a plain text response
+func (factory *Factory) Text(statusCode int, text string) *Response {
+	response := factory.New(statusCode, text)
+	response.SetHeader("Content-Type", factory.contentType("text/plain"))
+	return response
+}
+
+// JSON returns a JSON response encoded with the JSON options of the factory
+func (factory *Factory) JSON(statusCode int, data any) *JSONResponse {
+	response := factory.New(statusCode).JSON(data).SetOptions(factory.jsonOptions)
+	response.SetHeader("Content-Type", factory.contentType("application/json"))
+	return response
+}
+
+// XML returns a XML response encoded with the XML options of the factory
+func (factory *Factory) XML(statusCode int, data any) *XMLResponse {
+	response := factory.New(statusCode).XML(data).SetOptions(factory.xmlOptions)
+	response.SetHeader("Content-Type", factory.contentType("application/xml"))
+	return response
+}
+
+// View returns a page rendered by the template engine of the factory
+func (factory *Factory) View(statusCode int, name string, data any) *ViewResponse {
+	response := factory.New(statusCode).View(name, data).SetEngine(factory.engine)
+	response.SetHeader("Content-Type", factory.contentType("text/html"))
+	return response
+}
+
+// Reader returns a response streaming reader
+func (factory *Factory) Reader(statusCode int, reader io.Reader) *ReaderResponse {
+	return factory.New(statusCode).Reader(reader)
+}
+
+// Stream returns a streamed response
+func (factory *Factory) Stream(statusCode int, step func(io.Writer) bool) *StreamedResponse {
+	return factory.New(statusCode).Stream(step)
+}
+
+// Redirect returns a redirect response
+func (factory *Factory) Redirect(statusCode int, location string) *RedirectResponse {
+	return factory.New(statusCode).Redirect(location)
+}
+
+// File returns a file response
+func (factory *Factory) File(file string) *FileResponse {
+	return factory.New(http.StatusOK).File(file)
+}
+
+// Zip returns a ZIP archive response
+func (factory *Factory) Zip(filename string) *ArchiveResponse {
+	return factory.New(http.StatusOK).Zip(filename)
+}
+
+// Tar returns a tar archive response
+func (factory *Factory) Tar(filename string) *TarResponse {
+	return factory.New(http.StatusOK).Tar(filename)
+}
+
+// TarGz returns a gzip-compressed tar archive response
+func (factory *Factory) TarGz(filename string) *TarResponse {
+	return factory.New(http.StatusOK).TarGz(filename)
+}
+
+// Proxy returns a reverse proxy response
+func (factory *Factory) Proxy(target string) *ProxyResponse {
+	return factory.New(http.StatusOK).Proxy(target)
+}
+
+// ErrorRenderer returns the error renderer of the factory
+func (factory *Factory) ErrorRenderer() ErrorRenderer {
+	if factory.errorRenderer != nil {
+		return factory.errorRenderer
+	}
+	if defaultErrorRenderer != nil {
+		return defaultErrorRenderer
+	}
+	return PlainErrorRenderer
+}
+
+// Error returns the response reporting err to the client, it is converted with [AsHTTPError]
+func (factory *Factory) Error(r *http.Request, err error) http.Handler {
+	return factory.ErrorRenderer()(r, AsHTTPError(err, factory.debug))
+}
+
+// Handle creates a [Handler] using the error renderer and the debug mode of the factory
+func (factory *Factory) Handle(handler func(*http.Request) (http.Handler, error)) *Handler {
+	return Handle(handler).SetErrorRenderer(factory.ErrorRenderer()).SetDebug(factory.debug)
+}
+
+func (factory *Factory) contentType(mediaType string) string {
+	if factory.charset == "" {
+		return mediaType
+	}
+	return mediaType + "; charset=" + factory.charset
+}
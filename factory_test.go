@@ -0,0 +1,80 @@
+package response
+
+import (
+	"errors"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestFactory() *Factory {
+	templates := template.Must(template.New("hello").Parse(`<p>Hello {{.}}</p>`))
+	return NewFactory().
+		SetHeader("X-App", "demo").
+		SetCharset("utf-8").
+		SetJSONOptions(JSONOptions{Indent: "  ", DisableHTMLEscape: true}).
+		SetXMLOptions(XMLOptions{Header: true}).
+		SetTemplateEngine(NewHTMLTemplateEngine(templates)).
+		SetCookiePolicy(&CookiePolicy{HttpOnly: true}).
+		SetErrorRenderer(JSONErrorRenderer)
+}
+
+func TestFactory_JSON(t *testing.T) {
+	response := newTestFactory().JSON(http.StatusOK, map[string]string{"html": "<b>"})
+	response.SetCookie(&http.Cookie{Name: "session", Value: "abc"})
+	recorder := httptest.NewRecorder()
+	response.ServeHTTP(recorder, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, "demo", recorder.Header().Get("X-App"))
+	assert.Equal(t, "application/json; charset=utf-8", recorder.Header().Get("Content-Type"))
+	assert.Equal(t, "{\n  \"html\": \"<b>\"\n}", recorder.Body.String())
+	assert.True(t, recorder.Result().Cookies()[0].HttpOnly)
+}
+
+func TestFactory_XML(t *testing.T) {
+	type item struct {
+		Name string `xml:"name"`
+	}
+	recorder := httptest.NewRecorder()
+	newTestFactory().SetCharset("").XML(http.StatusOK, item{Name: "a"}).ServeHTTP(recorder, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, "application/xml", recorder.Header().Get("Content-Type"))
+	assert.Equal(t, "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<item><name>a</name></item>", recorder.Body.String())
+}
+
+func TestFactory_View(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	newTestFactory().View(http.StatusOK, "hello", "<world>").ServeHTTP(recorder, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, "text/html; charset=utf-8", recorder.Header().Get("Content-Type"))
+	assert.Equal(t, "<p>Hello &lt;world&gt;</p>", recorder.Body.String())
+
+	assert.Panics(t, func() {
+		NewFactory().View(http.StatusOK, "hello", nil).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	})
+}
+
+func TestFactory_Redirect(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	newTestFactory().Redirect(http.StatusFound, "/login").ServeHTTP(recorder, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, http.StatusFound, recorder.Code)
+	assert.Equal(t, "/login", recorder.Header().Get("Location"))
+	assert.Equal(t, "demo", recorder.Header().Get("X-App"))
+}
+
+func TestFactory_Error(t *testing.T) {
+	factory := newTestFactory()
+	request := httptest.NewRequest("GET", "/", nil)
+
+	recorder := httptest.NewRecorder()
+	factory.Error(request, NewHTTPError(http.StatusNotFound, "")).ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+	assert.JSONEq(t, `{"status":404,"message":"Not Found"}`, recorder.Body.String())
+
+	recorder = httptest.NewRecorder()
+	factory.SetDebug(true).Handle(func(r *http.Request) (http.Handler, error) {
+		return nil, errors.New("boom")
+	}).ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+	assert.JSONEq(t, `{"status":500,"message":"boom"}`, recorder.Body.String())
+}
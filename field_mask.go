@@ -0,0 +1,505 @@
+package response
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gopi-frame/exception"
+)
+
+// FieldMask selects the fields of a JSON response, in the style of google.protobuf.FieldMask.
+// A path names a field by its JSON name, nested fields are separated by dots, e.g. "author.name",
+// and "*" matches every field at its level. The fields of the items of a list are selected by the path of the list.
+type FieldMask struct {
+	Paths []string
+}
+
+// NewFieldMask creates a [FieldMask] with the paths
+func NewFieldMask(paths ...string) *FieldMask {
+	return &FieldMask{Paths: paths}
+}
+
+// ParseFieldMask parses a mask in the syntax of the fields query parameter: comma separated paths whose
+// segments are separated by dots or slashes, and whose fields can be grouped in parentheses,
+// e.g. "id,author(name,email),items/*/price"
+func ParseFieldMask(fields string) (*FieldMask, error) {
+	mask := NewFieldMask()
+	if strings.TrimSpace(fields) == "" {
+		return mask, nil
+	}
+	parser := &fieldMaskParser{input: fields}
+	paths, err := parser.list("")
+	if err != nil {
+		return nil, err
+	}
+	if parser.pos < len(parser.input) {
+		return nil, exception.New(fmt.Sprintf("unexpected `%c` at position %d of field mask", parser.input[parser.pos], parser.pos))
+	}
+	mask.Paths = paths
+	return mask, nil
+}
+
+// String returns the paths separated by commas
+func (mask *FieldMask) String() string {
+	return strings.Join(mask.Paths, ",")
+}
+
+// MarshalJSON encodes the mask as a string of comma separated paths, as google.protobuf.FieldMask
+func (mask FieldMask) MarshalJSON() ([]byte, error) {
+	return json.Marshal(strings.Join(mask.Paths, ","))
+}
+
+// UnmarshalJSON decodes a mask encoded as a string of comma separated paths
+func (mask *FieldMask) UnmarshalJSON(data []byte) error {
+	var paths string
+	if err := json.Unmarshal(data, &paths); err != nil {
+		return err
+	}
+	mask.Paths = nil
+	if paths != "" {
+		mask.Paths = strings.Split(paths, ",")
+	}
+	return nil
+}
+
+// tree returns the selected fields as a tree, nil when the mask selects everything
+func (mask *FieldMask) tree() *fieldMaskNode {
+	if mask == nil || len(mask.Paths) == 0 {
+		return nil
+	}
+	root := &fieldMaskNode{}
+	for _, path := range mask.Paths {
+		node := root
+		for _, segment := range strings.Split(path, ".") {
+			if node.children == nil {
+				node.children = make(map[string]*fieldMaskNode)
+			}
+			child, ok := node.children[segment]
+			if !ok {
+				child = &fieldMaskNode{}
+				node.children[segment] = child
+			}
+			node = child
+		}
+		node.all = true
+	}
+	return root
+}
+
+// fieldMaskParser parses the syntax of the fields query parameter
+type fieldMaskParser struct {
+	input string
+	pos   int
+}
+
+func (parser *fieldMaskParser) list(prefix string) ([]string, error) {
+	var paths []string
+	for {
+		path, err := parser.path(prefix)
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, path...)
+		if parser.pos >= len(parser.input) || parser.input[parser.pos] != ',' {
+			return paths, nil
+		}
+		parser.pos++
+	}
+}
+
+func (parser *fieldMaskParser) path(prefix string) ([]string, error) {
+	var segments []string
+	for {
+		start := parser.pos
+		for parser.pos < len(parser.input) && !strings.ContainsRune(",./()", rune(parser.input[parser.pos])) {
+			parser.pos++
+		}
+		segment := strings.TrimSpace(parser.input[start:parser.pos])
+		if segment == "" {
+			return nil, exception.New(fmt.Sprintf("missing field name at position %d of field mask", start))
+		}
+		segments = append(segments, segment)
+		if parser.pos < len(parser.input) && (parser.input[parser.pos] == '.' || parser.input[parser.pos] == '/') {
+			parser.pos++
+			continue
+		}
+		break
+	}
+	path := strings.Join(segments, ".")
+	if prefix != "" {
+		path = prefix + "." + path
+	}
+	if parser.pos >= len(parser.input) || parser.input[parser.pos] != '(' {
+		return []string{path}, nil
+	}
+	parser.pos++
+	paths, err := parser.list(path)
+	if err != nil {
+		return nil, err
+	}
+	if parser.pos >= len(parser.input) || parser.input[parser.pos] != ')' {
+		return nil, exception.New(fmt.Sprintf("missing `)` at position %d of field mask", parser.pos))
+	}
+	parser.pos++
+	return paths, nil
+}
+
+// fieldMaskNode is a selected field, all of it when all is true, otherwise the fields selected below it
+type fieldMaskNode struct {
+	all      bool
+	children map[string]*fieldMaskNode
+}
+
+// child returns the node of the field, nil when it is not selected
+func (node *fieldMaskNode) child(name string) *fieldMaskNode {
+	exact := node.children[name]
+	wildcard := node.children["*"]
+	if exact == nil || wildcard == nil {
+		if exact != nil {
+			return exact
+		}
+		return wildcard
+	}
+	return mergeFieldMaskNodes(exact, wildcard)
+}
+
+func mergeFieldMaskNodes(nodes ...*fieldMaskNode) *fieldMaskNode {
+	merged := &fieldMaskNode{}
+	grouped := make(map[string][]*fieldMaskNode)
+	for _, node := range nodes {
+		if node.all {
+			return node
+		}
+		for name, child := range node.children {
+			grouped[name] = append(grouped[name], child)
+		}
+	}
+	merged.children = make(map[string]*fieldMaskNode, len(grouped))
+	for name, children := range grouped {
+		merged.children[name] = mergeFieldMaskNodes(children...)
+	}
+	return merged
+}
+
+// fieldSelection is how a [JSONResponse] selects its fields.
+// The mask applies to the member of an envelope, such as the data of a page, when member is set.
+type fieldSelection struct {
+	mask       *FieldMask
+	queryParam string
+	strict     bool
+	member     string
+}
+
+// SetFieldMask sets the mask of the fields which are sent, it takes precedence over the query parameter
+func (jsonResponse *JSONResponse) SetFieldMask(mask *FieldMask) *JSONResponse {
+	jsonResponse.fields.mask = mask
+	return jsonResponse
+}
+
+// FieldsFromQuery selects the fields which are sent by the query parameter of the request, "fields" by default,
+// as parsed by [ParseFieldMask]
+func (jsonResponse *JSONResponse) FieldsFromQuery(param ...string) *JSONResponse {
+	jsonResponse.fields.queryParam = "fields"
+	if len(param) > 0 {
+		jsonResponse.fields.queryParam = param[0]
+	}
+	return jsonResponse
+}
+
+// SetStrictFields sets if a malformed mask or a path which is not a field of the data is answered with a
+// 400 problem response, otherwise malformed masks are ignored and unknown paths select nothing.
+// Paths are checked against the json fields of structs, the keys of maps and the values of interfaces are dynamic
+// and are never unknown.
+func (jsonResponse *JSONResponse) SetStrictFields(strict bool) *JSONResponse {
+	jsonResponse.fields.strict = strict
+	return jsonResponse
+}
+
+// project returns the fields of data selected for the request, or the problem of a rejected mask
+func (selection fieldSelection) project(r *http.Request, data any) (any, *ProblemDetails) {
+	tree, problem := selection.tree(r)
+	if problem != nil {
+		return nil, problem
+	}
+	envelope, ok := data.(map[string]any)
+	if selection.member == "" || !ok || tree == nil {
+		return selection.projectTree(data, tree)
+	}
+	projected, problem := selection.projectTree(envelope[selection.member], tree)
+	if problem != nil {
+		return nil, problem
+	}
+	// the envelope is copied so the data of the response is left as it is
+	members := make(map[string]any, len(envelope))
+	for key, value := range envelope {
+		members[key] = value
+	}
+	members[selection.member] = projected
+	return members, nil
+}
+
+// tree returns the fields selected for the request, nil when every field is sent, or the problem of a malformed mask
+func (selection fieldSelection) tree(r *http.Request) (*fieldMaskNode, *ProblemDetails) {
+	mask := selection.mask
+	if mask == nil && selection.queryParam != "" && r.URL.Query().Has(selection.queryParam) {
+		parsed, err := ParseFieldMask(r.URL.Query().Get(selection.queryParam))
+		if err != nil {
+			if !selection.strict {
+				return nil, nil
+			}
+			return nil, NewProblemDetails(http.StatusBadRequest, err.Error())
+		}
+		mask = parsed
+	}
+	return mask.tree(), nil
+}
+
+// projectTree returns the fields of data selected by tree, or the problem of the paths which are not fields of data
+func (selection fieldSelection) projectTree(data any, tree *fieldMaskNode) (any, *ProblemDetails) {
+	if tree == nil {
+		return data, nil
+	}
+	if selection.strict {
+		var unknown []string
+		unknownFieldPaths(reflect.TypeOf(data), tree, "", &unknown)
+		if len(unknown) > 0 {
+			sort.Strings(unknown)
+			problem := NewProblemDetails(http.StatusBadRequest, "Unknown field paths: "+strings.Join(unknown, ", "))
+			problem.Extensions = map[string]any{"fields": unknown}
+			return nil, problem
+		}
+	}
+	projected, _ := projectFields(reflect.ValueOf(data), tree)
+	return projected, nil
+}
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// isFieldMaskLeaf returns if values of the type are encoded as a whole, by their own marshaler
+func isFieldMaskLeaf(t reflect.Type) bool {
+	for _, marshaler := range []reflect.Type{jsonMarshalerType, textMarshalerType} {
+		if t.Implements(marshaler) || reflect.PointerTo(t).Implements(marshaler) {
+			return true
+		}
+	}
+	return false
+}
+
+// projectFields returns the fields of the value selected by the node, as encoding/json would encode them,
+// and false when a value without fields has fields selected, such as a string under a wildcard
+func projectFields(value reflect.Value, node *fieldMaskNode) (any, bool) {
+	if !value.IsValid() {
+		return nil, true
+	}
+	if node == nil || node.all {
+		return value.Interface(), true
+	}
+	if isFieldMaskLeaf(value.Type()) {
+		return nil, false
+	}
+	switch value.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if value.IsNil() {
+			return nil, true
+		}
+		return projectFields(value.Elem(), node)
+	case reflect.Struct:
+		selected := make(map[string]any)
+		for _, field := range cachedJSONFields(value.Type()) {
+			child := node.child(field.name)
+			if child == nil {
+				continue
+			}
+			fieldValue, err := value.FieldByIndexErr(field.index)
+			if err != nil || field.omitEmpty && isEmptyJSONValue(fieldValue) {
+				continue
+			}
+			if projected, ok := projectFields(fieldValue, child); ok {
+				selected[field.name] = projected
+			}
+		}
+		return selected, true
+	case reflect.Map:
+		if value.Type().Key().Kind() != reflect.String {
+			return nil, false
+		}
+		if value.IsNil() {
+			return nil, true
+		}
+		selected := make(map[string]any)
+		iterator := value.MapRange()
+		for iterator.Next() {
+			child := node.child(iterator.Key().String())
+			if child == nil {
+				continue
+			}
+			if projected, ok := projectFields(iterator.Value(), child); ok {
+				selected[iterator.Key().String()] = projected
+			}
+		}
+		return selected, true
+	case reflect.Slice, reflect.Array:
+		if value.Kind() == reflect.Slice && value.Type().Elem().Kind() == reflect.Uint8 {
+			return nil, false
+		}
+		if value.Kind() == reflect.Slice && value.IsNil() {
+			return nil, true
+		}
+		items := make([]any, 0, value.Len())
+		for i := 0; i < value.Len(); i++ {
+			if projected, ok := projectFields(value.Index(i), node); ok {
+				items = append(items, projected)
+			}
+		}
+		return items, true
+	}
+	return nil, false
+}
+
+// isEmptyJSONValue returns if the value is left out by the omitempty option of encoding/json
+func isEmptyJSONValue(value reflect.Value) bool {
+	switch value.Kind() {
+	case reflect.Map, reflect.Slice, reflect.Array, reflect.String:
+		return value.Len() == 0
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64, reflect.Interface, reflect.Pointer:
+		return value.IsZero()
+	}
+	return false
+}
+
+// unknownFieldPaths collects the selected paths which are not fields of the type
+func unknownFieldPaths(t reflect.Type, node *fieldMaskNode, prefix string, unknown *[]string) {
+	if t == nil || node == nil || node.all {
+		return
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	report := func() {
+		for name := range node.children {
+			*unknown = append(*unknown, joinFieldPath(prefix, name))
+		}
+	}
+	if isFieldMaskLeaf(t) {
+		report()
+		return
+	}
+	switch t.Kind() {
+	case reflect.Interface:
+	case reflect.Struct:
+		fields := cachedJSONFields(t)
+		for name, child := range node.children {
+			// the fields below a wildcard may differ from field to field
+			if name == "*" {
+				continue
+			}
+			index := slices.IndexFunc(fields, func(field jsonField) bool { return field.name == name })
+			if index < 0 {
+				*unknown = append(*unknown, joinFieldPath(prefix, name))
+				continue
+			}
+			unknownFieldPaths(fields[index].typ, child, joinFieldPath(prefix, name), unknown)
+		}
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			report()
+			return
+		}
+		for name, child := range node.children {
+			unknownFieldPaths(t.Elem(), child, joinFieldPath(prefix, name), unknown)
+		}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			report()
+			return
+		}
+		unknownFieldPaths(t.Elem(), node, prefix, unknown)
+	default:
+		report()
+	}
+}
+
+func joinFieldPath(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
+
+// jsonField is a struct field as encoded by encoding/json
+type jsonField struct {
+	name      string
+	index     []int
+	typ       reflect.Type
+	omitEmpty bool
+}
+
+var jsonFieldsCache sync.Map
+
+func cachedJSONFields(t reflect.Type) []jsonField {
+	if fields, ok := jsonFieldsCache.Load(t); ok {
+		return fields.([]jsonField)
+	}
+	fields, _ := jsonFieldsCache.LoadOrStore(t, jsonFields(t, nil, make(map[reflect.Type]bool)))
+	return fields.([]jsonField)
+}
+
+// jsonFields returns the fields of the struct type named by their json tags,
+// the fields of embedded structs are promoted unless a shallower field has the same name
+func jsonFields(t reflect.Type, index []int, visited map[reflect.Type]bool) []jsonField {
+	if visited[t] {
+		return nil
+	}
+	visited[t] = true
+	var fields, embedded []jsonField
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+		fieldIndex := append(append([]int(nil), index...), i)
+		if field.Anonymous && name == "" {
+			fieldType := field.Type
+			if fieldType.Kind() == reflect.Pointer {
+				fieldType = fieldType.Elem()
+			}
+			if fieldType.Kind() == reflect.Struct {
+				embedded = append(embedded, jsonFields(fieldType, fieldIndex, visited)...)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields = append(fields, jsonField{
+			name:      name,
+			index:     fieldIndex,
+			typ:       field.Type,
+			omitEmpty: slices.Contains(strings.Split(options, ","), "omitempty"),
+		})
+	}
+	for _, field := range embedded {
+		if !slices.ContainsFunc(fields, func(existing jsonField) bool { return existing.name == field.name }) {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
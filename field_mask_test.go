@@ -0,0 +1,143 @@
+package response
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type maskedAuthor struct {
+	Name  string `json:"name"`
+	Email string `json:"email,omitempty"`
+}
+
+type maskedAudit struct {
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type maskedArticle struct {
+	maskedAudit
+	ID       int               `json:"id"`
+	Title    string            `json:"title"`
+	Secret   string            `json:"-"`
+	Author   *maskedAuthor     `json:"author"`
+	Tags     []string          `json:"tags,omitempty"`
+	Comments []maskedAuthor    `json:"comments"`
+	Extra    map[string]any    `json:"extra"`
+	Labels   map[string]string `json:"labels"`
+}
+
+func newMaskedArticle() maskedArticle {
+	return maskedArticle{
+		maskedAudit: maskedAudit{CreatedAt: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)},
+		ID:          1,
+		Title:       "Masks",
+		Secret:      "hidden",
+		Author:      &maskedAuthor{Name: "Alice", Email: "alice@example.com"},
+		Comments:    []maskedAuthor{{Name: "Bob"}, {Name: "Carol", Email: "carol@example.com"}},
+		Extra:       map[string]any{"views": 10, "source": map[string]any{"name": "rss", "url": "https://example.com"}},
+		Labels:      map[string]string{"lang": "en"},
+	}
+}
+
+func TestParseFieldMask(t *testing.T) {
+	cases := map[string][]string{
+		"":                               {},
+		"id,title":                       {"id", "title"},
+		"author/name, author.email":      {"author.name", "author.email"},
+		"id,author(name,email),tags":     {"id", "author.name", "author.email", "tags"},
+		"extra(source(name,url)),*.name": {"extra.source.name", "extra.source.url", "*.name"},
+	}
+	for fields, paths := range cases {
+		mask, err := ParseFieldMask(fields)
+		if assert.Nil(t, err, fields) {
+			assert.ElementsMatch(t, paths, mask.Paths, fields)
+		}
+	}
+	for _, fields := range []string{"id,", "author(name", "author)", "a..b", "(name)"} {
+		_, err := ParseFieldMask(fields)
+		assert.NotNil(t, err, fields)
+	}
+}
+
+func TestFieldMask_JSON(t *testing.T) {
+	content, err := json.Marshal(NewFieldMask("id", "author.name"))
+	assert.Nil(t, err)
+	assert.Equal(t, `"id,author.name"`, string(content))
+	var mask FieldMask
+	assert.Nil(t, json.Unmarshal([]byte(`"title,tags"`), &mask))
+	assert.Equal(t, []string{"title", "tags"}, mask.Paths)
+	assert.Equal(t, "title,tags", mask.String())
+}
+
+func TestJSONResponse_FieldMask(t *testing.T) {
+	cases := []struct {
+		name string
+		mask *FieldMask
+		body string
+	}{
+		{"top level", NewFieldMask("id", "title", "created_at"), `{"id":1,"title":"Masks","created_at":"2024-01-02T03:04:05Z"}`},
+		{"nested", NewFieldMask("author.name", "extra.source.name"), `{"author":{"name":"Alice"},"extra":{"source":{"name":"rss"}}}`},
+		{"list items", NewFieldMask("comments.email"), `{"comments":[{},{"email":"carol@example.com"}]}`},
+		{"wildcard", NewFieldMask("*.name", "id"), `{"id":1,"author":{"name":"Alice"},"comments":[{"name":"Bob"},{"name":"Carol"}],"extra":{},"labels":{}}`},
+		{"omitempty", NewFieldMask("tags", "id"), `{"id":1}`},
+		{"hidden", NewFieldMask("Secret", "id"), `{"id":1}`},
+		{"whole field wins", NewFieldMask("author", "author.name"), `{"author":{"name":"Alice","email":"alice@example.com"}}`},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			New(http.StatusOK).JSON(newMaskedArticle()).SetFieldMask(c.mask).ServeHTTP(recorder, httptest.NewRequest("GET", "/", nil))
+			assert.JSONEq(t, c.body, recorder.Body.String())
+		})
+	}
+}
+
+func TestJSONResponse_FieldsFromQuery(t *testing.T) {
+	response := New(http.StatusOK).JSON([]maskedArticle{newMaskedArticle()}).FieldsFromQuery()
+
+	recorder := httptest.NewRecorder()
+	response.ServeHTTP(recorder, httptest.NewRequest("GET", "/articles?fields=id,author(name)", nil))
+	assert.JSONEq(t, `[{"id":1,"author":{"name":"Alice"}}]`, recorder.Body.String())
+
+	recorder = httptest.NewRecorder()
+	response.ServeHTTP(recorder, httptest.NewRequest("GET", "/articles?fields=id,author(name", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), `"title":"Masks"`)
+
+	recorder = httptest.NewRecorder()
+	New(http.StatusOK).JSON(map[string]any{"a": 1, "b": 2}).FieldsFromQuery("only").
+		ServeHTTP(recorder, httptest.NewRequest("GET", "/?only=b,c", nil))
+	assert.JSONEq(t, `{"b":2}`, recorder.Body.String())
+}
+
+func TestJSONResponse_StrictFields(t *testing.T) {
+	response := New(http.StatusOK).JSON(&maskedArticle{}).FieldsFromQuery().SetStrictFields(true)
+
+	recorder := httptest.NewRecorder()
+	response.ServeHTTP(recorder, httptest.NewRequest("GET", "/articles/1?fields=id,author(name,age),comments.id,extra.any,created_at.year,*.x", nil))
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	assert.Equal(t, "application/problem+json", recorder.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{
+		"type": "about:blank",
+		"title": "Bad Request",
+		"status": 400,
+		"detail": "Unknown field paths: author.age, comments.id, created_at.year",
+		"instance": "/articles/1",
+		"fields": ["author.age", "comments.id", "created_at.year"]
+	}`, recorder.Body.String())
+
+	recorder = httptest.NewRecorder()
+	response.ServeHTTP(recorder, httptest.NewRequest("GET", "/articles/1?fields=id)", nil))
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	assert.False(t, response.HasHeader("Content-Type"))
+
+	recorder = httptest.NewRecorder()
+	response.ServeHTTP(recorder, httptest.NewRequest("GET", "/articles/1?fields=id", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.JSONEq(t, `{"id":0}`, recorder.Body.String())
+}
@@ -17,8 +17,20 @@ func (fileResponse *FileResponse) SetFile(filename string) *FileResponse {
 	return fileResponse
 }
 
+// DeleteFileAfterSend removes the file once the response is sent, see Terminate
+func (fileResponse *FileResponse) DeleteFileAfterSend() *FileResponse {
+	fileResponse.Terminate(func(SendResult) {
+		_ = os.Remove(fileResponse.filename)
+	})
+	return fileResponse
+}
+
 // ServeHTTP reads the file content and sends it
 func (fileResponse *FileResponse) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	fileResponse.send(w, r, fileResponse.serve)
+}
+
+func (fileResponse *FileResponse) serve(w http.ResponseWriter, r *http.Request) {
 	f, err := os.Open(fileResponse.filename)
 	if err != nil {
 		panic(err)
@@ -29,5 +41,5 @@ func (fileResponse *FileResponse) ServeHTTP(w http.ResponseWriter, r *http.Reque
 		}
 	}()
 	fileResponse.SetReader(f)
-	fileResponse.ReaderResponse.ServeHTTP(w, r)
+	fileResponse.ReaderResponse.serve(w, r)
 }
@@ -0,0 +1,402 @@
+package response
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/gopi-frame/exception"
+)
+
+const (
+	// HALMediaType is the media type of HAL documents
+	HALMediaType = "application/hal+json"
+	// HALFormsMediaType is the media type of HAL-FORMS documents
+	HALFormsMediaType = "application/prs.hal-forms+json"
+)
+
+// HALLink is a link of a HAL resource, a link whose href contains a URI template is marked as templated
+type HALLink struct {
+	Href        string `json:"href"`
+	Templated   bool   `json:"templated,omitempty"`
+	Type        string `json:"type,omitempty"`
+	Deprecation string `json:"deprecation,omitempty"`
+	Name        string `json:"name,omitempty"`
+	Profile     string `json:"profile,omitempty"`
+	Title       string `json:"title,omitempty"`
+	Hreflang    string `json:"hreflang,omitempty"`
+}
+
+// HALTemplate is a HAL-FORMS template, describing a request which can be sent to the resource
+type HALTemplate struct {
+	Title       string        `json:"title,omitempty"`
+	Method      string        `json:"method"`
+	ContentType string        `json:"contentType,omitempty"`
+	Target      string        `json:"target,omitempty"`
+	Properties  []HALProperty `json:"properties,omitempty"`
+}
+
+// HALProperty is a property of a HAL-FORMS template
+type HALProperty struct {
+	Name        string `json:"name"`
+	Type        string `json:"type,omitempty"`
+	Prompt      string `json:"prompt,omitempty"`
+	Placeholder string `json:"placeholder,omitempty"`
+	Value       string `json:"value,omitempty"`
+	Regex       string `json:"regex,omitempty"`
+	Required    bool   `json:"required,omitempty"`
+	ReadOnly    bool   `json:"readOnly,omitempty"`
+	Templated   bool   `json:"templated,omitempty"`
+	MinLength   int    `json:"minLength,omitempty"`
+	MaxLength   int    `json:"maxLength,omitempty"`
+}
+
+// HALResource is a HAL resource: the state, whose members are sent at the top level of the resource,
+// with its _links, _embedded resources and HAL-FORMS _templates
+type HALResource struct {
+	state      any
+	links      map[string][]HALLink
+	linkArrays map[string]bool
+	curies     []HALLink
+	embedded   map[string]halEmbedded
+	templates  map[string]HALTemplate
+}
+
+// halEmbedded is an embedded resource, or a collection encoded item by item
+type halEmbedded struct {
+	resource *HALResource
+	items    any
+	decorate func(index int, resource *HALResource)
+}
+
+// NewHALResource creates a [HALResource] of the state, a struct or a map encoded as a JSON object
+func NewHALResource(state any) *HALResource {
+	return &HALResource{state: state}
+}
+
+// SetState sets the state of the resource
+func (resource *HALResource) SetState(state any) *HALResource {
+	resource.state = state
+	return resource
+}
+
+// Self adds the self link
+func (resource *HALResource) Self(href string) *HALResource {
+	return resource.AddLink("self", HALLink{Href: href})
+}
+
+// AddLink adds a link of the relation, a relation with one link is sent as an object and with more as an array
+func (resource *HALResource) AddLink(rel string, link HALLink) *HALResource {
+	if resource.links == nil {
+		resource.links = make(map[string][]HALLink)
+	}
+	if strings.Contains(link.Href, "{") {
+		link.Templated = true
+	}
+	resource.links[rel] = append(resource.links[rel], link)
+	return resource
+}
+
+// AddLinks adds links of the relation, which is always sent as an array
+func (resource *HALResource) AddLinks(rel string, links ...HALLink) *HALResource {
+	if resource.linkArrays == nil {
+		resource.linkArrays = make(map[string]bool)
+	}
+	resource.linkArrays[rel] = true
+	for _, link := range links {
+		resource.AddLink(rel, link)
+	}
+	if resource.links[rel] == nil {
+		resource.links[rel] = make([]HALLink, 0)
+	}
+	return resource
+}
+
+// AddCurie adds a curie, the href is a URI template with a {rel} variable documenting the relations
+// prefixed with "<name>:"
+func (resource *HALResource) AddCurie(name, href string) *HALResource {
+	resource.curies = append(resource.curies, HALLink{Name: name, Href: href, Templated: true})
+	return resource
+}
+
+// Embed embeds a resource of the relation
+func (resource *HALResource) Embed(rel string, embedded *HALResource) *HALResource {
+	if resource.embedded == nil {
+		resource.embedded = make(map[string]halEmbedded)
+	}
+	resource.embedded[rel] = halEmbedded{resource: embedded}
+	return resource
+}
+
+// EmbedCollection embeds the items of a slice as resources of the relation, sent as an array.
+// Items which are not a [*HALResource] are the state of a resource passed to decorate to add links,
+// the resource is reused from item to item, so large collections are encoded without a copy.
+func (resource *HALResource) EmbedCollection(rel string, items any, decorate func(index int, resource *HALResource)) *HALResource {
+	if kind := reflect.ValueOf(items).Kind(); items != nil && kind != reflect.Slice && kind != reflect.Array {
+		panic(exception.NewArgumentException("items", items, "items must be a slice or an array"))
+	}
+	if resource.embedded == nil {
+		resource.embedded = make(map[string]halEmbedded)
+	}
+	resource.embedded[rel] = halEmbedded{items: items, decorate: decorate}
+	return resource
+}
+
+// AddTemplate adds a HAL-FORMS template, the template of the resource itself is keyed by "default"
+func (resource *HALResource) AddTemplate(key string, template HALTemplate) *HALResource {
+	if resource.templates == nil {
+		resource.templates = make(map[string]HALTemplate)
+	}
+	resource.templates[key] = template
+	return resource
+}
+
+// halEncoder encodes resources with the links resolved against the request
+type halEncoder struct {
+	buf   *bytes.Buffer
+	base  *url.URL
+	forms bool
+}
+
+// resolve resolves the href against the request URL, the URI template in it is kept as it is.
+// An href which starts with a template is left as it is, and a query template replaces the query of the request URL.
+func (encoder *halEncoder) resolve(href string) string {
+	reference, template, _ := strings.Cut(href, "{")
+	if reference == "" {
+		return href
+	}
+	if template != "" {
+		template = "{" + template
+	}
+	parsed, err := url.Parse(reference)
+	if err != nil {
+		return href
+	}
+	resolved := encoder.base.ResolveReference(parsed)
+	if parsed.RawQuery == "" && (strings.HasPrefix(template, "{?") || strings.HasPrefix(template, "{&")) {
+		resolved.RawQuery = ""
+		resolved.ForceQuery = false
+	}
+	return resolved.String() + template
+}
+
+func (encoder *halEncoder) encode(resource *HALResource, root bool) error {
+	state := []byte("{}")
+	if resource.state != nil {
+		content, err := json.Marshal(resource.state)
+		if err != nil {
+			return err
+		}
+		state = bytes.TrimSpace(content)
+	}
+	if len(state) < 2 || state[0] != '{' {
+		return exception.New("the state of a HAL resource must be encoded as a JSON object")
+	}
+	members := 0
+	member := func(name string) {
+		if members > 0 {
+			encoder.buf.WriteByte(',')
+		}
+		members++
+		encoder.buf.WriteString(`"` + name + `":`)
+	}
+	encoder.buf.WriteByte('{')
+	if len(resource.links) > 0 || len(resource.curies) > 0 {
+		member("_links")
+		if err := encoder.encodeLinks(resource); err != nil {
+			return err
+		}
+	}
+	if len(resource.embedded) > 0 {
+		member("_embedded")
+		if err := encoder.encodeEmbedded(resource); err != nil {
+			return err
+		}
+	}
+	if root && encoder.forms && len(resource.templates) > 0 {
+		member("_templates")
+		templates := make(map[string]HALTemplate, len(resource.templates))
+		for key, template := range resource.templates {
+			if template.Method == "" {
+				template.Method = http.MethodGet
+			}
+			if template.Target != "" {
+				template.Target = encoder.resolve(template.Target)
+			}
+			templates[key] = template
+		}
+		content, err := json.Marshal(templates)
+		if err != nil {
+			return err
+		}
+		encoder.buf.Write(content)
+	}
+	if inner := bytes.TrimSpace(state[1 : len(state)-1]); len(inner) > 0 {
+		if members > 0 {
+			encoder.buf.WriteByte(',')
+		}
+		encoder.buf.Write(inner)
+	}
+	encoder.buf.WriteByte('}')
+	return nil
+}
+
+func (encoder *halEncoder) encodeLinks(resource *HALResource) error {
+	links := make(map[string]any, len(resource.links)+1)
+	resolve := func(links []HALLink) []HALLink {
+		resolved := make([]HALLink, len(links))
+		for i, link := range links {
+			link.Href = encoder.resolve(link.Href)
+			resolved[i] = link
+		}
+		return resolved
+	}
+	for rel, relLinks := range resource.links {
+		resolved := resolve(relLinks)
+		if len(resolved) == 1 && !resource.linkArrays[rel] {
+			links[rel] = resolved[0]
+		} else {
+			links[rel] = resolved
+		}
+	}
+	if len(resource.curies) > 0 {
+		links["curies"] = resolve(resource.curies)
+	}
+	content, err := json.Marshal(links)
+	if err != nil {
+		return err
+	}
+	encoder.buf.Write(content)
+	return nil
+}
+
+func (encoder *halEncoder) encodeEmbedded(resource *HALResource) error {
+	rels := make([]string, 0, len(resource.embedded))
+	for rel := range resource.embedded {
+		rels = append(rels, rel)
+	}
+	sort.Strings(rels)
+	encoder.buf.WriteByte('{')
+	for i, rel := range rels {
+		if i > 0 {
+			encoder.buf.WriteByte(',')
+		}
+		name, err := json.Marshal(rel)
+		if err != nil {
+			return err
+		}
+		encoder.buf.Write(name)
+		encoder.buf.WriteByte(':')
+		embedded := resource.embedded[rel]
+		if embedded.resource != nil {
+			if err := encoder.encode(embedded.resource, false); err != nil {
+				return err
+			}
+			continue
+		}
+		encoder.buf.WriteByte('[')
+		if embedded.items != nil {
+			items := reflect.ValueOf(embedded.items)
+			item := &HALResource{}
+			for index := 0; index < items.Len(); index++ {
+				if index > 0 {
+					encoder.buf.WriteByte(',')
+				}
+				value := items.Index(index).Interface()
+				if itemResource, ok := value.(*HALResource); ok {
+					if err := encoder.encode(itemResource, false); err != nil {
+						return err
+					}
+					continue
+				}
+				*item = HALResource{state: value}
+				if embedded.decorate != nil {
+					embedded.decorate(index, item)
+				}
+				if err := encoder.encode(item, false); err != nil {
+					return err
+				}
+			}
+		}
+		encoder.buf.WriteByte(']')
+	}
+	encoder.buf.WriteByte('}')
+	return nil
+}
+
+// HALResponse is used to send a [HALResource] as application/hal+json.
+// Relative hrefs and template targets are resolved against the URL of the request, or the base URL when it is set.
+// The HAL-FORMS templates of the resource are only sent, as application/prs.hal-forms+json,
+// to requests which accept that media type.
+type HALResponse struct {
+	*JSONResponse
+	resource *HALResource
+	baseURL  *url.URL
+}
+
+// SetResource sets the resource
+func (halResponse *HALResponse) SetResource(resource *HALResource) *HALResponse {
+	halResponse.resource = resource
+	return halResponse
+}
+
+// SetBaseURL sets the URL relative hrefs are resolved against, for services behind a proxy
+func (halResponse *HALResponse) SetBaseURL(baseURL string) *HALResponse {
+	base, err := url.Parse(baseURL)
+	if err != nil || !base.IsAbs() {
+		panic(exception.NewArgumentException("baseURL", baseURL, "baseURL must be an absolute URL"))
+	}
+	halResponse.baseURL = base
+	return halResponse
+}
+
+// ServeHTTP sends the resource
+func (halResponse *HALResponse) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	halResponse.send(w, r, halResponse.serve)
+}
+
+func (halResponse *HALResponse) serve(w http.ResponseWriter, r *http.Request) {
+	resource := halResponse.resource
+	if resource == nil {
+		resource = NewHALResource(nil)
+	}
+	base := halResponse.baseURL
+	if base == nil {
+		parsed, err := url.Parse(requestURL(r))
+		if err != nil {
+			panic(err)
+		}
+		base = parsed
+	}
+	encoder := &halEncoder{
+		buf:   new(bytes.Buffer),
+		base:  base,
+		forms: len(resource.templates) > 0 && halFormsAcceptable(r.Header.Get("Accept")),
+	}
+	if err := encoder.encode(resource, true); err != nil {
+		panic(err)
+	}
+	// the media type is set on a copy so the response can still be served to other requests
+	response := *halResponse.Response
+	response.headers = halResponse.headers.Clone()
+	if len(resource.templates) > 0 {
+		response.Vary("Accept")
+	}
+	if !response.HasHeader("Content-Type") {
+		if encoder.forms {
+			response.SetHeader("Content-Type", HALFormsMediaType)
+		} else {
+			response.SetHeader("Content-Type", HALMediaType)
+		}
+	}
+	(&JSONResponse{Response: &response, data: json.RawMessage(encoder.buf.Bytes()), options: halResponse.options}).serve(w, r)
+}
+
+// halFormsAcceptable returns if the Accept header lists the HAL-FORMS media type with q above 0
+func halFormsAcceptable(accept string) bool {
+	return acceptQuality(parseAccept(accept), isMediaType(HALFormsMediaType)) > 0
+}
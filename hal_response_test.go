@@ -0,0 +1,125 @@
+package response
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type halOrder struct {
+	ID     int    `json:"id"`
+	Status string `json:"status"`
+}
+
+func newHALOrders() *HALResource {
+	orders := []halOrder{{ID: 1, Status: "shipped"}, {ID: 2, Status: "processing"}}
+	return NewHALResource(map[string]int{"count": 2}).
+		Self("/orders?page=2").
+		AddLink("next", HALLink{Href: "orders?page=3"}).
+		AddLink("find", HALLink{Href: "/orders{?id}"}).
+		AddLink("search", HALLink{Href: "{?q}"}).
+		AddLink("sort", HALLink{Href: "orders{?sort}"}).
+		AddLinks("admin", HALLink{Href: "https://admin.example.com/orders", Title: "Admin"}).
+		AddCurie("acme", "/docs/rels/{rel}").
+		AddLink("acme:customers", HALLink{Href: "/customers"}).
+		Embed("acme:summary", NewHALResource(struct {
+			Total float64 `json:"total"`
+		}{Total: 30.5}).Self("/orders/summary")).
+		EmbedCollection("acme:orders", orders, func(index int, resource *HALResource) {
+			resource.Self(fmt.Sprintf("/orders/%d", orders[index].ID))
+		})
+}
+
+func TestHALResponse(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	New(http.StatusOK).HAL(newHALOrders()).ServeHTTP(recorder, httptest.NewRequest("GET", "/api/orders?page=2", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, HALMediaType, recorder.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{
+		"_links": {
+			"self": {"href": "http://example.com/orders?page=2"},
+			"next": {"href": "http://example.com/api/orders?page=3"},
+			"find": {"href": "http://example.com/orders{?id}", "templated": true},
+			"search": {"href": "{?q}", "templated": true},
+			"sort": {"href": "http://example.com/api/orders{?sort}", "templated": true},
+			"admin": [{"href": "https://admin.example.com/orders", "title": "Admin"}],
+			"acme:customers": {"href": "http://example.com/customers"},
+			"curies": [{"name": "acme", "href": "http://example.com/docs/rels/{rel}", "templated": true}]
+		},
+		"_embedded": {
+			"acme:orders": [
+				{"_links": {"self": {"href": "http://example.com/orders/1"}}, "id": 1, "status": "shipped"},
+				{"_links": {"self": {"href": "http://example.com/orders/2"}}, "id": 2, "status": "processing"}
+			],
+			"acme:summary": {"_links": {"self": {"href": "http://example.com/orders/summary"}}, "total": 30.5}
+		},
+		"count": 2
+	}`, recorder.Body.String())
+}
+
+func TestHALResponse_BaseURL(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	New(http.StatusOK).HAL(NewHALResource(nil).Self("orders/1")).SetBaseURL("https://api.example.com/v1/").
+		ServeHTTP(recorder, httptest.NewRequest("GET", "/orders/1", nil))
+	assert.Equal(t, `{"_links":{"self":{"href":"https://api.example.com/v1/orders/1"}}}`, recorder.Body.String())
+
+	assert.Panics(t, func() {
+		New(http.StatusOK).HAL(nil).SetBaseURL("/v1")
+	})
+}
+
+func TestHALResponse_Forms(t *testing.T) {
+	response := New(http.StatusOK).HAL(NewHALResource(halOrder{ID: 1, Status: "processing"}).
+		Self("/orders/1").
+		AddTemplate("default", HALTemplate{
+			Title:       "Update the order",
+			Method:      http.MethodPut,
+			ContentType: "application/json",
+			Properties: []HALProperty{
+				{Name: "status", Prompt: "Status", Required: true, Regex: "^(processing|shipped)$"},
+			},
+		}).
+		AddTemplate("cancel", HALTemplate{Target: "/orders/1/cancel"}))
+
+	request := httptest.NewRequest("GET", "/orders/1", nil)
+	request.Header.Set("Accept", HALFormsMediaType+", "+HALMediaType+";q=0.9")
+	recorder := httptest.NewRecorder()
+	response.ServeHTTP(recorder, request)
+	assert.Equal(t, HALFormsMediaType, recorder.Header().Get("Content-Type"))
+	assert.Equal(t, "Accept", recorder.Header().Get("Vary"))
+	assert.JSONEq(t, `{
+		"_links": {"self": {"href": "http://example.com/orders/1"}},
+		"_templates": {
+			"default": {
+				"title": "Update the order",
+				"method": "PUT",
+				"contentType": "application/json",
+				"properties": [{"name": "status", "prompt": "Status", "required": true, "regex": "^(processing|shipped)$"}]
+			},
+			"cancel": {"method": "GET", "target": "http://example.com/orders/1/cancel"}
+		},
+		"id": 1,
+		"status": "processing"
+	}`, recorder.Body.String())
+
+	for _, accept := range []string{"", HALFormsMediaType + ";q=0, " + HALMediaType, "application/prs.hal-forms+json-seq"} {
+		request := httptest.NewRequest("GET", "/orders/1", nil)
+		request.Header.Set("Accept", accept)
+		recorder = httptest.NewRecorder()
+		response.ServeHTTP(recorder, request)
+		assert.Equal(t, HALMediaType, recorder.Header().Get("Content-Type"), accept)
+		assert.NotContains(t, recorder.Body.String(), "_templates", accept)
+	}
+}
+
+func TestHALResource_Invalid(t *testing.T) {
+	assert.Panics(t, func() {
+		NewHALResource(nil).EmbedCollection("items", "not a slice", nil)
+	})
+	assert.Panics(t, func() {
+		New(http.StatusOK).HAL(NewHALResource([]int{1})).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	})
+}
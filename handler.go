@@ -0,0 +1,147 @@
+package response
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+)
+
+// Responder is the interface implemented by all response types
+type Responder interface {
+	http.Handler
+	SetStatusCode(statusCode int)
+	StatusCode() int
+	SetHeader(key, value string, replace ...bool)
+	Headers() http.Header
+	SetCookie(cookie *http.Cookie)
+	Cookies() []*http.Cookie
+}
+
+// Handler adapts a function which returns the response of a request into an [http.Handler].
+// The returned response is sent, a nil response sends 204 No Content, and a returned error
+// is converted with [AsHTTPError] and sent through the error renderer, except [ValidationErrors]
+// which are sent as a [ValidationErrorResponse].
+// Panics are recovered and reported as internal server errors, unless the response was already partially sent,
+// in which case the panic goes on to abort the connection.
+// The Terminate callbacks of the responses sent while serving a request start once ServeHTTP returns.
+//
+// The defaults of the route, headers set with SetHeader, are sent with every response unless it sets them itself.
+type Handler struct {
+	handler  func(*http.Request) (http.Handler, error)
+	renderer ErrorRenderer
+	headers  http.Header
+	debug    bool
+	reporter func(r *http.Request, err *HTTPError)
+}
+
+// Handle creates a [Handler] from a function returning any response type
+func Handle[T http.Handler](handler func(*http.Request) (T, error)) *Handler {
+	return &Handler{
+		handler: func(r *http.Request) (http.Handler, error) {
+			response, err := handler(r)
+			if err != nil || isNilHandler(response) {
+				return nil, err
+			}
+			return response, nil
+		},
+		headers: make(http.Header),
+	}
+}
+
+// SetErrorRenderer sets the error renderer of the route, it overrides the default error renderer
+func (handler *Handler) SetErrorRenderer(renderer ErrorRenderer) *Handler {
+	handler.renderer = renderer
+	return handler
+}
+
+// ErrorRenderer returns the error renderer of the route
+func (handler *Handler) ErrorRenderer() ErrorRenderer {
+	if handler.renderer != nil {
+		return handler.renderer
+	}
+	if defaultErrorRenderer != nil {
+		return defaultErrorRenderer
+	}
+	return PlainErrorRenderer
+}
+
+// SetHeader sets a default header of the route
+func (handler *Handler) SetHeader(key, value string) *Handler {
+	handler.headers.Set(key, value)
+	return handler
+}
+
+// SetDebug sets if the messages of internal errors are shown to the client
+func (handler *Handler) SetDebug(debug bool) *Handler {
+	handler.debug = debug
+	return handler
+}
+
+// SetReporter sets the function which is called with server errors and recovered panics, e.g. to log them
+func (handler *Handler) SetReporter(reporter func(r *http.Request, err *HTTPError)) *Handler {
+	handler.reporter = reporter
+	return handler
+}
+
+// ServeHTTP calls the function and sends its response
+func (handler *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	for key, values := range handler.headers {
+		w.Header()[key] = append([]string(nil), values...)
+	}
+	r, terminate := withTerminateQueue(r)
+	if terminate != nil {
+		defer terminate.run()
+	}
+	writer := &sendWriter{ResponseWriter: w, statusCode: http.StatusOK}
+	defer func() {
+		recovered := recover()
+		if recovered == nil {
+			return
+		}
+		if recovered == http.ErrAbortHandler || writer.wroteHeader {
+			panic(recovered)
+		}
+		err, ok := recovered.(error)
+		if !ok {
+			err = fmt.Errorf("%v", recovered)
+		}
+		// the headers set before the panic, e.g. cookies, belong to the response which failed
+		clear(w.Header())
+		for key, values := range handler.headers {
+			w.Header()[key] = append([]string(nil), values...)
+		}
+		handler.renderError(w, r, AsHTTPError(fmt.Errorf("panic: %w", err), handler.debug))
+	}()
+	response, err := handler.handler(r)
+	var validationErrors *ValidationErrors
+	if errors.As(err, &validationErrors) {
+		ValidationFailed(validationErrors).ServeHTTP(writer, r)
+		return
+	}
+	if err != nil {
+		handler.renderError(w, r, AsHTTPError(err, handler.debug))
+		return
+	}
+	if response == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	response.ServeHTTP(writer, r)
+}
+
+func (handler *Handler) renderError(w http.ResponseWriter, r *http.Request, err *HTTPError) {
+	if handler.reporter != nil && err.StatusCode >= http.StatusInternalServerError {
+		handler.reporter(r, err)
+	}
+	handler.ErrorRenderer()(r, err).ServeHTTP(w, r)
+}
+
+// isNilHandler returns if handler is nil or a nil pointer
+func isNilHandler(handler http.Handler) bool {
+	if handler == nil {
+		return true
+	}
+	value := reflect.ValueOf(handler)
+	return value.Kind() == reflect.Pointer && value.IsNil()
+}
@@ -0,0 +1,134 @@
+package response
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	_ Responder = (*Response)(nil)
+	_ Responder = (*JSONResponse)(nil)
+	_ Responder = (*XMLResponse)(nil)
+	_ Responder = (*ReaderResponse)(nil)
+	_ Responder = (*RedirectResponse)(nil)
+	_ Responder = (*FileResponse)(nil)
+	_ Responder = (*StreamedResponse)(nil)
+	_ Responder = (*HtmlResponse)(nil)
+	_ Responder = (*ArchiveResponse)(nil)
+	_ Responder = (*TarResponse)(nil)
+	_ Responder = (*ProxyResponse)(nil)
+)
+
+type statusError struct{}
+
+func (statusError) Error() string   { return "quota exceeded" }
+func (statusError) StatusCode() int { return http.StatusTooManyRequests }
+
+func TestHandle(t *testing.T) {
+	t.Run("response", func(t *testing.T) {
+		handler := Handle(func(r *http.Request) (*JSONResponse, error) {
+			return New(http.StatusOK).JSON(map[string]string{"path": r.URL.Path}), nil
+		}).SetHeader("X-Route", "users").SetHeader("Content-Type", "text/plain")
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest("GET", "/users", nil))
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Equal(t, "users", recorder.Header().Get("X-Route"))
+		assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+		assert.JSONEq(t, `{"path":"/users"}`, recorder.Body.String())
+	})
+
+	t.Run("nil response", func(t *testing.T) {
+		handler := Handle(func(r *http.Request) (*Response, error) {
+			return nil, nil
+		})
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest("DELETE", "/users/1", nil))
+		assert.Equal(t, http.StatusNoContent, recorder.Code)
+	})
+
+	t.Run("http error", func(t *testing.T) {
+		handler := Handle(func(r *http.Request) (Responder, error) {
+			return nil, NewHTTPError(http.StatusNotFound, "user not found")
+		}).SetErrorRenderer(JSONErrorRenderer)
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest("GET", "/users/1", nil))
+		assert.Equal(t, http.StatusNotFound, recorder.Code)
+		assert.JSONEq(t, `{"status":404,"message":"user not found"}`, recorder.Body.String())
+	})
+
+	t.Run("status error", func(t *testing.T) {
+		handler := Handle(func(r *http.Request) (http.Handler, error) {
+			return nil, statusError{}
+		}).SetErrorRenderer(PlainErrorRenderer)
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest("GET", "/", nil))
+		assert.Equal(t, http.StatusTooManyRequests, recorder.Code)
+		assert.Equal(t, "quota exceeded", recorder.Body.String())
+	})
+
+	t.Run("internal error", func(t *testing.T) {
+		var reported *HTTPError
+		handler := Handle(func(r *http.Request) (http.Handler, error) {
+			return nil, errors.New("database is down")
+		}).SetErrorRenderer(PlainErrorRenderer).SetReporter(func(r *http.Request, err *HTTPError) {
+			reported = err
+		})
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest("GET", "/", nil))
+		assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+		assert.Equal(t, "Internal Server Error", recorder.Body.String())
+		if assert.NotNil(t, reported) {
+			assert.EqualError(t, reported.Err, "database is down")
+		}
+
+		recorder = httptest.NewRecorder()
+		handler.SetDebug(true).ServeHTTP(recorder, httptest.NewRequest("GET", "/", nil))
+		assert.Equal(t, "database is down", recorder.Body.String())
+	})
+
+	t.Run("panic", func(t *testing.T) {
+		handler := Handle(func(r *http.Request) (*JSONResponse, error) {
+			return New(http.StatusOK).JSON(make(chan int)), nil
+		}).SetErrorRenderer(ProblemErrorRenderer)
+		recorder := httptest.NewRecorder()
+		assert.NotPanics(t, func() {
+			handler.ServeHTTP(recorder, httptest.NewRequest("GET", "/broken", nil))
+		})
+		assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+		assert.Equal(t, "application/problem+json", recorder.Header().Get("Content-Type"))
+		assert.JSONEq(t, `{"type":"about:blank","title":"Internal Server Error","status":500,"instance":"/broken"}`, recorder.Body.String())
+	})
+
+	t.Run("panic clears headers", func(t *testing.T) {
+		handler := Handle(func(r *http.Request) (http.HandlerFunc, error) {
+			return func(w http.ResponseWriter, r *http.Request) {
+				http.SetCookie(w, &http.Cookie{Name: "session", Value: "abc"})
+				w.Header().Set("Content-Type", "text/csv")
+				panic("broken export")
+			}, nil
+		}).SetHeader("X-Service", "export").SetErrorRenderer(PlainErrorRenderer)
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest("GET", "/", nil))
+		assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+		assert.Empty(t, recorder.Header().Values("Set-Cookie"))
+		assert.Equal(t, "text/plain; charset=utf-8", recorder.Header().Get("Content-Type"))
+		assert.Equal(t, "export", recorder.Header().Get("X-Service"))
+	})
+
+	t.Run("panic after write", func(t *testing.T) {
+		handler := Handle(func(r *http.Request) (*StreamedResponse, error) {
+			return New(http.StatusOK).Stream(func(w io.Writer) bool {
+				_, _ = w.Write([]byte("partial"))
+				panic("broken stream")
+			}), nil
+		})
+		assert.PanicsWithValue(t, "broken stream", func() {
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+		})
+	})
+}
@@ -0,0 +1,561 @@
+package response
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// HAR is an HTTP Archive 1.2 document
+type HAR struct {
+	Log HARLog `json:"log"`
+}
+
+// HARLog is the root of an HTTP Archive
+type HARLog struct {
+	Version string     `json:"version"`
+	Creator HARCreator `json:"creator"`
+	Entries []HAREntry `json:"entries"`
+}
+
+// HARCreator names the application which created the archive
+type HARCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// HAREntry is a request and response pair, Time is the total duration in milliseconds
+type HAREntry struct {
+	StartedDateTime time.Time   `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         HARRequest  `json:"request"`
+	Response        HARResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         HARTimings  `json:"timings"`
+}
+
+// HARRequest is the request of an entry, unknown sizes are -1
+type HARRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []HARCookie    `json:"cookies"`
+	Headers     []HARNameValue `json:"headers"`
+	QueryString []HARNameValue `json:"queryString"`
+	PostData    *HARPostData   `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int64          `json:"bodySize"`
+}
+
+// HARResponse is the response of an entry, unknown sizes are -1
+type HARResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []HARCookie    `json:"cookies"`
+	Headers     []HARNameValue `json:"headers"`
+	Content     HARContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int64          `json:"bodySize"`
+}
+
+// HARCookie is a request or response cookie
+type HARCookie struct {
+	Name     string     `json:"name"`
+	Value    string     `json:"value"`
+	Path     string     `json:"path,omitempty"`
+	Domain   string     `json:"domain,omitempty"`
+	Expires  *time.Time `json:"expires,omitempty"`
+	HTTPOnly bool       `json:"httpOnly,omitempty"`
+	Secure   bool       `json:"secure,omitempty"`
+}
+
+// HARNameValue is a header or query string parameter
+type HARNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// HARPostData is the request body, Text is omitted when the body is binary
+type HARPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+	Comment  string `json:"comment,omitempty"`
+}
+
+// HARContent is the response body, a binary body is base64 encoded and its Encoding is "base64"
+type HARContent struct {
+	Size     int64  `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	Encoding string `json:"encoding,omitempty"`
+	Comment  string `json:"comment,omitempty"`
+}
+
+// HARTimings are the phases of an entry in milliseconds.
+// Wait is the time until the handler wrote the status line, and Receive the time it took to write the body.
+type HARTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+// harEntryIndent is the indent of the entries in an archive file
+const harEntryIndent = "      "
+
+// harHead is the beginning of an archive file up to the opening bracket of the entries,
+// harEmptyClosing the end of a file without entries and harClosing the end of a file after an entry
+var harHead, harEmptyClosing, harClosing = func() ([]byte, []byte, []byte) {
+	content, err := json.MarshalIndent(HAR{Log: HARLog{
+		Version: "1.2",
+		Creator: HARCreator{Name: "github.com/gopi-frame/response", Version: "1.0"},
+		Entries: []HAREntry{},
+	}}, "", "  ")
+	if err != nil {
+		panic(err)
+	}
+	index := bytes.LastIndex(content, []byte("[]")) + 1
+	closing := append([]byte("\n"+harEntryIndent[2:]), content[index:]...)
+	return content[:index], content[index:], closing
+}()
+
+// HARRecorder is a middleware which records the requests and responses of handlers into HTTP Archive files.
+// Entries are appended to the current file of the output directory as they complete,
+// a new file is started after a number of entries (SetMaxEntries) and the oldest files are removed (SetMaxFiles).
+// Bodies are kept up to a size limit (SetMaxBodySize), the request body as far as the handler read it, and the values of sensitive headers and cookies are
+// replaced with [Redacted] (SetRedactHeaders).
+// Write errors do not affect the served responses, the last one is returned by Err.
+type HARRecorder struct {
+	dir           string
+	prefix        string
+	maxEntries    int
+	maxFiles      int
+	maxBodySize   int
+	sampleRate    float64
+	redactHeaders []string
+	now           func() time.Time
+	random        func() float64
+	mu            sync.Mutex
+	entries       int
+	offset        int64
+	file          string
+	sequence      int
+	files         []string
+	err           error
+}
+
+// NewHARRecorder creates a new [HARRecorder] writing to dir, every request is recorded by default
+func NewHARRecorder(dir string) *HARRecorder {
+	return &HARRecorder{
+		dir:           dir,
+		prefix:        "session",
+		maxEntries:    1000,
+		maxBodySize:   1 << 20,
+		sampleRate:    1,
+		redactHeaders: []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"},
+		now:           time.Now,
+		random:        rand.Float64,
+	}
+}
+
+// SetPrefix sets the prefix of the file names, files are named "<prefix>-<start time>-<sequence>.har"
+func (recorder *HARRecorder) SetPrefix(prefix string) *HARRecorder {
+	recorder.prefix = prefix
+	return recorder
+}
+
+// SetMaxEntries sets the number of entries after which a new file is started
+func (recorder *HARRecorder) SetMaxEntries(entries int) *HARRecorder {
+	recorder.maxEntries = entries
+	return recorder
+}
+
+// SetMaxFiles sets the number of files kept, the oldest files are removed, zero keeps all files.
+// Files already in the output directory with the same prefix count toward the limit.
+func (recorder *HARRecorder) SetMaxFiles(files int) *HARRecorder {
+	recorder.maxFiles = files
+	return recorder
+}
+
+// SetMaxBodySize sets the number of body bytes recorded, longer bodies are truncated
+func (recorder *HARRecorder) SetMaxBodySize(size int) *HARRecorder {
+	recorder.maxBodySize = size
+	return recorder
+}
+
+// SetSampleRate sets the fraction of requests which are recorded, from 0 to 1
+func (recorder *HARRecorder) SetSampleRate(rate float64) *HARRecorder {
+	recorder.sampleRate = rate
+	return recorder
+}
+
+// SetRedactHeaders sets the headers whose values are redacted, redacting Cookie or Set-Cookie redacts cookie values.
+// Authorization, Proxy-Authorization, Cookie and Set-Cookie are redacted by default.
+func (recorder *HARRecorder) SetRedactHeaders(names ...string) *HARRecorder {
+	recorder.redactHeaders = make([]string, 0, len(names))
+	for _, name := range names {
+		recorder.redactHeaders = append(recorder.redactHeaders, http.CanonicalHeaderKey(name))
+	}
+	return recorder
+}
+
+// Err returns the last error which occurred while writing a file
+func (recorder *HARRecorder) Err() error {
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	return recorder.err
+}
+
+// Handler wraps next with the recorder
+func (recorder *HARRecorder) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if recorder.sampleRate < 1 && recorder.random() >= recorder.sampleRate {
+			next.ServeHTTP(w, r)
+			return
+		}
+		started := recorder.now()
+		var requestBody *limitedBuffer
+		if r.Body != nil && r.Body != http.NoBody {
+			requestBody = &limitedBuffer{limit: recorder.maxBodySize}
+			r.Body = &teeReadCloser{Reader: io.TeeReader(r.Body, requestBody), Closer: r.Body}
+		}
+		writer := &harResponseWriter{
+			ResponseWriter: w,
+			now:            recorder.now,
+			statusCode:     http.StatusOK,
+			body:           limitedBuffer{limit: recorder.maxBodySize},
+		}
+		defer func() {
+			finished := recorder.now()
+			recorder.record(recorder.entry(r, requestBody, writer, started, finished))
+		}()
+		next.ServeHTTP(writer, r)
+	})
+}
+
+func (recorder *HARRecorder) entry(r *http.Request, requestBody *limitedBuffer, writer *harResponseWriter, started, finished time.Time) HAREntry {
+	if writer.wroteAt.IsZero() {
+		writer.wroteAt = finished
+	}
+	requestHeader := r.Header.Clone()
+	if requestHeader.Get("Host") == "" {
+		requestHeader.Set("Host", r.Host)
+	}
+	request := HARRequest{
+		Method:      r.Method,
+		URL:         requestURL(r),
+		HTTPVersion: r.Proto,
+		Cookies:     recorder.harCookies(r.Cookies(), "Cookie"),
+		Headers:     recorder.harHeaders(requestHeader),
+		QueryString: harNameValues(r.URL.Query()),
+		HeadersSize: -1,
+	}
+	if requestBody != nil {
+		request.BodySize = requestBody.size
+		mimeType := r.Header.Get("Content-Type")
+		if text, _, comment := harBody(requestBody, mimeType); text != "" || comment != "" {
+			request.PostData = &HARPostData{MimeType: mimeType, Text: text, Comment: comment}
+		}
+	}
+
+	responseHeader := writer.Header()
+	mimeType := responseHeader.Get("Content-Type")
+	if mimeType == "" && writer.body.Len() > 0 {
+		mimeType = http.DetectContentType(writer.body.Bytes())
+	}
+	text, encoding, comment := harBody(&writer.body, mimeType)
+	response := HARResponse{
+		Status:      writer.statusCode,
+		StatusText:  http.StatusText(writer.statusCode),
+		HTTPVersion: r.Proto,
+		Cookies:     recorder.harCookies((&http.Response{Header: responseHeader}).Cookies(), "Set-Cookie"),
+		Headers:     recorder.harHeaders(responseHeader),
+		Content: HARContent{
+			Size:     writer.body.size,
+			MimeType: mimeType,
+			Text:     text,
+			Encoding: encoding,
+			Comment:  comment,
+		},
+		RedirectURL: responseHeader.Get("Location"),
+		HeadersSize: -1,
+		BodySize:    writer.body.size,
+	}
+	return HAREntry{
+		StartedDateTime: started,
+		Time:            milliseconds(finished.Sub(started)),
+		Request:         request,
+		Response:        response,
+		Timings: HARTimings{
+			Wait:    milliseconds(writer.wroteAt.Sub(started)),
+			Receive: milliseconds(finished.Sub(writer.wroteAt)),
+		},
+	}
+}
+
+// record appends the entry to the current file, starting a new file when it is full.
+// The entry is written over the closing brackets of the document, which are written again after it,
+// so the file stays a valid archive without being rewritten.
+func (recorder *HARRecorder) record(entry HAREntry) {
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	if recorder.file == "" || (recorder.maxEntries > 0 && recorder.entries >= recorder.maxEntries) {
+		recorder.rotate(entry.StartedDateTime)
+	}
+	content, err := json.MarshalIndent(entry, harEntryIndent, "  ")
+	if err != nil {
+		recorder.err = err
+		return
+	}
+	chunk := make([]byte, 0, len(content)+len(harEntryIndent)+2)
+	if recorder.entries > 0 {
+		chunk = append(chunk, ',')
+	}
+	chunk = append(append(append(chunk, '\n'), harEntryIndent...), content...)
+	if err := recorder.writeAt(append(chunk, harClosing...), recorder.offset); err != nil {
+		recorder.err = err
+		return
+	}
+	recorder.offset += int64(len(chunk))
+	recorder.entries++
+}
+
+func (recorder *HARRecorder) rotate(now time.Time) {
+	if recorder.file == "" {
+		recorder.files = recorder.existingFiles()
+	}
+	recorder.sequence++
+	recorder.entries = 0
+	recorder.offset = int64(len(harHead))
+	recorder.file = filepath.Join(recorder.dir, fmt.Sprintf("%s-%s-%04d.har", recorder.prefix, now.UTC().Format("20060102T150405"), recorder.sequence))
+	if err := os.WriteFile(recorder.file, append(append([]byte{}, harHead...), harEmptyClosing...), 0o644); err != nil {
+		recorder.err = err
+	}
+	recorder.files = append(recorder.files, recorder.file)
+	for recorder.maxFiles > 0 && len(recorder.files) > recorder.maxFiles {
+		if err := os.Remove(recorder.files[0]); err != nil && !os.IsNotExist(err) {
+			recorder.err = err
+		}
+		recorder.files = recorder.files[1:]
+	}
+}
+
+// existingFiles returns the files left in the output directory by earlier recorders with the same prefix, oldest first,
+// so they count toward the number of files kept
+func (recorder *HARRecorder) existingFiles() []string {
+	entries, err := os.ReadDir(recorder.dir)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			recorder.err = err
+		}
+		return nil
+	}
+	var files []string
+	for _, entry := range entries {
+		name, ok := strings.CutPrefix(entry.Name(), recorder.prefix+"-")
+		if !ok || !entry.Type().IsRegular() || !harFileSuffix(name) {
+			continue
+		}
+		files = append(files, filepath.Join(recorder.dir, entry.Name()))
+	}
+	// the names start with the UTC start time, so they sort by age
+	slices.Sort(files)
+	return files
+}
+
+// harFileSuffix reports whether name is the "<start time>-<sequence>.har" part of a file name written by rotate
+func harFileSuffix(name string) bool {
+	stamp, sequence, ok := strings.Cut(strings.TrimSuffix(name, ".har"), "-")
+	if !ok || len(name) == len(stamp)+len(sequence)+1 {
+		return false
+	}
+	if _, err := time.Parse("20060102T150405", stamp); err != nil {
+		return false
+	}
+	_, err := strconv.Atoi(sequence)
+	return err == nil
+}
+
+// writeAt writes content at the offset of the current file
+func (recorder *HARRecorder) writeAt(content []byte, offset int64) error {
+	file, err := os.OpenFile(recorder.file, os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	if _, err := file.WriteAt(content, offset); err != nil {
+		_ = file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func (recorder *HARRecorder) redacted(name string) bool {
+	return slices.Contains(recorder.redactHeaders, http.CanonicalHeaderKey(name))
+}
+
+func (recorder *HARRecorder) harHeaders(header http.Header) []HARNameValue {
+	pairs := harNameValues(header)
+	for i := range pairs {
+		if recorder.redacted(pairs[i].Name) {
+			pairs[i].Value = Redacted
+		}
+	}
+	return pairs
+}
+
+func (recorder *HARRecorder) harCookies(cookies []*http.Cookie, header string) []HARCookie {
+	harCookies := make([]HARCookie, 0, len(cookies))
+	for _, cookie := range cookies {
+		harCookie := HARCookie{
+			Name:     cookie.Name,
+			Value:    cookie.Value,
+			Path:     cookie.Path,
+			Domain:   cookie.Domain,
+			HTTPOnly: cookie.HttpOnly,
+			Secure:   cookie.Secure,
+		}
+		if !cookie.Expires.IsZero() {
+			expires := cookie.Expires
+			harCookie.Expires = &expires
+		}
+		if recorder.redacted(header) {
+			harCookie.Value = Redacted
+		}
+		harCookies = append(harCookies, harCookie)
+	}
+	return harCookies
+}
+
+// harNameValues flattens headers or query values, sorted by name
+func harNameValues(values map[string][]string) []HARNameValue {
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	pairs := make([]HARNameValue, 0, len(names))
+	for _, name := range names {
+		for _, value := range values[name] {
+			pairs = append(pairs, HARNameValue{Name: name, Value: value})
+		}
+	}
+	return pairs
+}
+
+// harBody returns the recorded body as text, or base64 encoded when it is binary
+func harBody(body *limitedBuffer, mimeType string) (text, encoding, comment string) {
+	if body.truncated() {
+		comment = fmt.Sprintf("truncated to %d of %d bytes", body.Len(), body.size)
+	}
+	if body.Len() == 0 {
+		return "", "", comment
+	}
+	if isTextMimeType(mimeType) && utf8.Valid(body.Bytes()) {
+		return body.String(), "", comment
+	}
+	return base64.StdEncoding.EncodeToString(body.Bytes()), "base64", comment
+}
+
+func isTextMimeType(mimeType string) bool {
+	mediaType, _, err := mime.ParseMediaType(mimeType)
+	if err != nil {
+		return false
+	}
+	return strings.HasPrefix(mediaType, "text/") ||
+		strings.HasSuffix(mediaType, "+json") || strings.HasSuffix(mediaType, "+xml") ||
+		slices.Contains([]string{"application/json", "application/xml", "application/javascript", "application/x-www-form-urlencoded"}, mediaType)
+}
+
+func requestURL(r *http.Request) string {
+	if r.URL.IsAbs() {
+		return r.URL.String()
+	}
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host + r.URL.RequestURI()
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// limitedBuffer keeps the first limit bytes written to it and counts all of them
+type limitedBuffer struct {
+	bytes.Buffer
+	limit int
+	size  int64
+}
+
+func (buffer *limitedBuffer) Write(p []byte) (int, error) {
+	buffer.size += int64(len(p))
+	if remaining := buffer.limit - buffer.Len(); remaining > 0 {
+		buffer.Buffer.Write(p[:min(remaining, len(p))])
+	}
+	return len(p), nil
+}
+
+func (buffer *limitedBuffer) truncated() bool {
+	return int64(buffer.Len()) < buffer.size
+}
+
+type teeReadCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// harResponseWriter records the response written by a handler
+type harResponseWriter struct {
+	http.ResponseWriter
+	now         func() time.Time
+	statusCode  int
+	wroteHeader bool
+	wroteAt     time.Time
+	body        limitedBuffer
+}
+
+func (writer *harResponseWriter) WriteHeader(statusCode int) {
+	writer.recordHeader(statusCode)
+	writer.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (writer *harResponseWriter) Write(b []byte) (int, error) {
+	// the implicit 200 is left to the wrapped writer, which detects the content type on the first write
+	writer.recordHeader(http.StatusOK)
+	_, _ = writer.body.Write(b)
+	return writer.ResponseWriter.Write(b)
+}
+
+func (writer *harResponseWriter) Flush() {
+	if flusher, ok := writer.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (writer *harResponseWriter) recordHeader(statusCode int) {
+	if !writer.wroteHeader {
+		writer.wroteHeader = true
+		writer.statusCode = statusCode
+		writer.wroteAt = writer.now()
+	}
+}
+
+// Unwrap returns the wrapped writer for [http.ResponseController]
+func (writer *harResponseWriter) Unwrap() http.ResponseWriter {
+	return writer.ResponseWriter
+}
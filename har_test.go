@@ -0,0 +1,165 @@
+package response
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestHARRecorder(t *testing.T) (*HARRecorder, *fakeClock, string) {
+	dir := t.TempDir()
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	recorder := NewHARRecorder(dir)
+	recorder.now = clock.Now
+	return recorder, clock, dir
+}
+
+func readHARFiles(t *testing.T, dir string) map[string]HAR {
+	files, err := filepath.Glob(filepath.Join(dir, "*.har"))
+	assert.Nil(t, err)
+	hars := make(map[string]HAR, len(files))
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		assert.Nil(t, err)
+		var har HAR
+		assert.Nil(t, json.Unmarshal(content, &har))
+		hars[filepath.Base(file)] = har
+	}
+	return hars
+}
+
+func TestHARRecorder_Handler(t *testing.T) {
+	recorder, clock, dir := newTestHARRecorder(t)
+	handler := recorder.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		clock.Advance(20 * time.Millisecond)
+		response := New(http.StatusCreated).JSON(map[string]string{"name": string(body)})
+		response.SetCookie(&http.Cookie{Name: "session", Value: "secret", HttpOnly: true})
+		response.ServeHTTP(w, r)
+		clock.Advance(5 * time.Millisecond)
+	}))
+
+	request := httptest.NewRequest("POST", "http://example.com/users?page=2", strings.NewReader("alice"))
+	request.Header.Set("Content-Type", "text/plain")
+	request.Header.Set("Authorization", "Bearer token")
+	request.AddCookie(&http.Cookie{Name: "theme", Value: "dark"})
+	recorder2 := httptest.NewRecorder()
+	handler.ServeHTTP(recorder2, request)
+	assert.Equal(t, http.StatusCreated, recorder2.Code)
+	assert.JSONEq(t, `{"name":"alice"}`, recorder2.Body.String())
+	assert.Nil(t, recorder.Err())
+
+	hars := readHARFiles(t, dir)
+	har, ok := hars["session-20240101T000000-0001.har"]
+	if !assert.True(t, ok) || !assert.Len(t, har.Log.Entries, 1) {
+		return
+	}
+	assert.Equal(t, "1.2", har.Log.Version)
+	entry := har.Log.Entries[0]
+	assert.Equal(t, 25.0, entry.Time)
+	assert.Equal(t, HARTimings{Wait: 20, Receive: 5}, entry.Timings)
+
+	assert.Equal(t, "POST", entry.Request.Method)
+	assert.Equal(t, "http://example.com/users?page=2", entry.Request.URL)
+	assert.Equal(t, []HARNameValue{{Name: "page", Value: "2"}}, entry.Request.QueryString)
+	assert.Contains(t, entry.Request.Headers, HARNameValue{Name: "Authorization", Value: Redacted})
+	assert.Equal(t, []HARCookie{{Name: "theme", Value: Redacted}}, entry.Request.Cookies)
+	assert.Equal(t, &HARPostData{MimeType: "text/plain", Text: "alice"}, entry.Request.PostData)
+	assert.Equal(t, int64(5), entry.Request.BodySize)
+
+	assert.Equal(t, http.StatusCreated, entry.Response.Status)
+	assert.Equal(t, "Created", entry.Response.StatusText)
+	assert.Contains(t, entry.Response.Headers, HARNameValue{Name: "Set-Cookie", Value: Redacted})
+	assert.Equal(t, []HARCookie{{Name: "session", Value: Redacted, HTTPOnly: true}}, entry.Response.Cookies)
+	assert.Equal(t, "application/json", entry.Response.Content.MimeType)
+	assert.JSONEq(t, `{"name":"alice"}`, entry.Response.Content.Text)
+	assert.Empty(t, entry.Response.Content.Encoding)
+}
+
+func TestHARRecorder_Body(t *testing.T) {
+	recorder, _, dir := newTestHARRecorder(t)
+	recorder.SetMaxBodySize(4).SetRedactHeaders()
+	handler := recorder.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/binary" {
+			w.Header().Set("Content-Type", "image/png")
+			_, _ = w.Write([]byte{0x89, 'P', 'N', 'G'})
+			return
+		}
+		_, _ = w.Write([]byte("0123456789"))
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/binary", nil))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/text", nil))
+
+	for _, har := range readHARFiles(t, dir) {
+		if !assert.Len(t, har.Log.Entries, 2) {
+			return
+		}
+		binary := har.Log.Entries[0].Response.Content
+		assert.Equal(t, HARContent{Size: 4, MimeType: "image/png", Text: "iVBORw==", Encoding: "base64"}, binary)
+		text := har.Log.Entries[1].Response.Content
+		assert.Equal(t, HARContent{Size: 10, MimeType: "text/plain; charset=utf-8", Text: "0123", Comment: "truncated to 4 of 10 bytes"}, text)
+	}
+}
+
+func TestHARRecorder_Rotation(t *testing.T) {
+	recorder, clock, dir := newTestHARRecorder(t)
+	recorder.SetPrefix("debug").SetMaxEntries(2).SetMaxFiles(2)
+	handler := recorder.Handler(New(http.StatusNoContent))
+	for i := 0; i < 5; i++ {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+		clock.Advance(time.Second)
+		// the current file is a valid archive after every entry
+		hars := readHARFiles(t, dir)
+		assert.Len(t, hars[filepath.Base(recorder.file)].Log.Entries, i%2+1)
+	}
+
+	hars := readHARFiles(t, dir)
+	assert.Len(t, hars, 2)
+	assert.Len(t, hars["debug-20240101T000002-0002.har"].Log.Entries, 2)
+	assert.Len(t, hars["debug-20240101T000004-0003.har"].Log.Entries, 1)
+}
+
+func TestHARRecorder_RotationExistingFiles(t *testing.T) {
+	recorder, _, dir := newTestHARRecorder(t)
+	for _, name := range []string{"debug-20231231T000001-0002.har", "debug-20231231T000000-0001.har", "debug-notes.har", "other-20231231T000000-0001.har"} {
+		assert.Nil(t, os.WriteFile(filepath.Join(dir, name), nil, 0o644))
+	}
+	recorder.SetPrefix("debug").SetMaxFiles(2)
+	recorder.Handler(New(http.StatusNoContent)).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+
+	names, err := filepath.Glob(filepath.Join(dir, "*.har"))
+	assert.Nil(t, err)
+	for i, name := range names {
+		names[i] = filepath.Base(name)
+	}
+	assert.Equal(t, []string{"debug-20231231T000001-0002.har", "debug-20240101T000000-0001.har", "debug-notes.har", "other-20231231T000000-0001.har"}, names)
+}
+
+func TestHARRecorder_SetSampleRate(t *testing.T) {
+	recorder, _, dir := newTestHARRecorder(t)
+	samples := []float64{0.1, 0.9, 0.2, 0.7}
+	recorder.random = func() float64 {
+		sample := samples[0]
+		samples = samples[1:]
+		return sample
+	}
+	recorder.SetSampleRate(0.5)
+	handler := recorder.Handler(New(http.StatusOK, "ok"))
+	for i := 0; i < 4; i++ {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest("GET", "/", nil))
+		assert.Equal(t, "ok", recorder.Body.String())
+	}
+
+	for _, har := range readHARFiles(t, dir) {
+		assert.Len(t, har.Log.Entries, 2)
+	}
+}
@@ -0,0 +1,218 @@
+package response
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+var (
+	globalBeforeSend []func(*Response, *http.Request)
+	globalAfterSend  []func(SendResult)
+	globalTerminate  []func(SendResult)
+)
+
+// BeforeSend registers a hook which runs for every response before its headers are written,
+// hooks should be registered before responses are served.
+func BeforeSend(hook func(*Response, *http.Request)) {
+	globalBeforeSend = append(globalBeforeSend, hook)
+}
+
+// AfterSend registers a hook which runs for every response after it is written
+func AfterSend(hook func(SendResult)) {
+	globalAfterSend = append(globalAfterSend, hook)
+}
+
+// Terminate registers a callback which runs for every response once it is sent, see [Response.Terminate]
+func Terminate(callback func(SendResult)) {
+	globalTerminate = append(globalTerminate, callback)
+}
+
+// ClearHooks removes the hooks registered with [BeforeSend], [AfterSend] and [Terminate]
+func ClearHooks() {
+	globalBeforeSend = nil
+	globalAfterSend = nil
+	globalTerminate = nil
+}
+
+// SendResult describes a sent response.
+//   - StatusCode: The status code written, 200 when the response wrote nothing.
+//   - BytesWritten: The number of body bytes written.
+//   - Duration: The time it took to send the response.
+//   - Err: The error the response panicked with, the panic goes on after the hooks.
+type SendResult struct {
+	Response     *Response
+	Request      *http.Request
+	StatusCode   int
+	BytesWritten int64
+	Duration     time.Duration
+	Err          error
+}
+
+// BeforeSend adds a hook which runs before the headers of the response are written, after the global hooks.
+// The hook can change the status code, headers and cookies, and the content of responses which send it.
+func (response *Response) BeforeSend(hook func(*Response, *http.Request)) {
+	response.beforeSend = append(response.beforeSend, hook)
+}
+
+// AfterSend adds a hook which runs after the response is written, after the global hooks
+func (response *Response) AfterSend(hook func(SendResult)) {
+	response.afterSend = append(response.afterSend, hook)
+}
+
+// Terminate adds a callback which runs once the response is sent, after the global callbacks.
+// Callbacks run in their own goroutine, so they do not delay the handler, and they run whether or not the request context is ever done.
+// A response served by a [Handler] starts them when the ServeHTTP of the outermost Handler returns, so they run after
+// everything the handler does with the request, e.g. responses sent through middlewares which wrap it.
+// Otherwise they start as soon as the response is written and the after send hooks have run, while the handler which
+// served it may still be running; net/http gives no notice of the connection being released in either case.
+func (response *Response) Terminate(callback func(SendResult)) {
+	response.terminate = append(response.terminate, callback)
+}
+
+// runBeforeSend runs the global and the response hooks which run before the headers are written
+func (response *Response) runBeforeSend(r *http.Request) {
+	for _, hook := range globalBeforeSend {
+		hook(response, r)
+	}
+	for _, hook := range response.beforeSend {
+		hook(response, r)
+	}
+}
+
+// send serves the response with serve and runs the after send hooks and terminate callbacks
+func (response *Response) send(w http.ResponseWriter, r *http.Request, serve func(http.ResponseWriter, *http.Request)) {
+	request := r
+	r = withCSPNonce(r)
+	afterSend := append(append([]func(SendResult){}, globalAfterSend...), response.afterSend...)
+	terminate := append(append([]func(SendResult){}, globalTerminate...), response.terminate...)
+	if len(afterSend) == 0 && len(terminate) == 0 {
+		serve(w, r)
+		return
+	}
+	writer := &sendWriter{ResponseWriter: w, statusCode: http.StatusOK}
+	start := time.Now()
+	defer func() {
+		recovered := recover()
+		result := SendResult{
+			Response:     response,
+			Request:      request,
+			StatusCode:   writer.statusCode,
+			BytesWritten: writer.written,
+			Duration:     time.Since(start),
+		}
+		if recovered != nil {
+			if err, ok := recovered.(error); ok {
+				result.Err = err
+			} else {
+				result.Err = fmt.Errorf("%v", recovered)
+			}
+		}
+		for _, hook := range afterSend {
+			hook(result)
+		}
+		if len(terminate) > 0 {
+			if queue, ok := r.Context().Value(terminateQueueKey{}).(*terminateQueue); ok {
+				queue.add(terminate, result)
+			} else {
+				go runTerminate(terminate, result)
+			}
+		}
+		if recovered != nil {
+			panic(recovered)
+		}
+	}()
+	serve(writer, r)
+}
+
+// sendWriter counts the bytes written to a response
+type sendWriter struct {
+	http.ResponseWriter
+	statusCode  int
+	wroteHeader bool
+	written     int64
+}
+
+func (writer *sendWriter) WriteHeader(statusCode int) {
+	if !writer.wroteHeader {
+		writer.wroteHeader = true
+		writer.statusCode = statusCode
+	}
+	writer.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (writer *sendWriter) Write(b []byte) (int, error) {
+	// the implicit 200 is left to the wrapped writer, which detects the content type on the first write
+	writer.wroteHeader = true
+	n, err := writer.ResponseWriter.Write(b)
+	writer.written += int64(n)
+	return n, err
+}
+
+func (writer *sendWriter) Flush() {
+	if flusher, ok := writer.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap returns the wrapped writer for [http.ResponseController]
+func (writer *sendWriter) Unwrap() http.ResponseWriter {
+	return writer.ResponseWriter
+}
+
+// terminateQueueKey is the request context key of the terminate callbacks of the responses sent by a [Handler]
+type terminateQueueKey struct{}
+
+// terminateQueue holds the terminate callbacks of a request until its handler returns
+type terminateQueue struct {
+	mu      sync.Mutex
+	pending []func()
+	done    bool
+}
+
+// withTerminateQueue returns the request with a terminate queue in its context and the queue to run once it is served,
+// a request which already has one is returned as it is with a nil queue, the callbacks are run by the handler which added it
+func withTerminateQueue(r *http.Request) (*http.Request, *terminateQueue) {
+	if _, ok := r.Context().Value(terminateQueueKey{}).(*terminateQueue); ok {
+		return r, nil
+	}
+	queue := new(terminateQueue)
+	return r.WithContext(context.WithValue(r.Context(), terminateQueueKey{}, queue)), queue
+}
+
+func (queue *terminateQueue) add(callbacks []func(SendResult), result SendResult) {
+	queue.mu.Lock()
+	defer queue.mu.Unlock()
+	// a response sent after the handler returned, e.g. from a goroutine, does not wait
+	if queue.done {
+		go runTerminate(callbacks, result)
+		return
+	}
+	queue.pending = append(queue.pending, func() {
+		runTerminate(callbacks, result)
+	})
+}
+
+// run starts the queued callbacks in their own goroutine
+func (queue *terminateQueue) run() {
+	queue.mu.Lock()
+	pending := queue.pending
+	queue.pending, queue.done = nil, true
+	queue.mu.Unlock()
+	if len(pending) == 0 {
+		return
+	}
+	go func() {
+		for _, callback := range pending {
+			callback()
+		}
+	}()
+}
+
+func runTerminate(callbacks []func(SendResult), result SendResult) {
+	for _, callback := range callbacks {
+		callback(result)
+	}
+}
@@ -0,0 +1,171 @@
+package response
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResponse_BeforeSend(t *testing.T) {
+	defer ClearHooks()
+	var calls []string
+	BeforeSend(func(response *Response, r *http.Request) {
+		calls = append(calls, "global")
+		response.SetHeader("X-Request-Path", r.URL.Path)
+	})
+
+	response := New(http.StatusOK).JSON(map[string]string{"name": "alice"})
+	response.BeforeSend(func(response *Response, r *http.Request) {
+		calls = append(calls, "response")
+		response.SetStatusCode(http.StatusAccepted)
+	})
+	recorder := httptest.NewRecorder()
+	response.ServeHTTP(recorder, httptest.NewRequest("GET", "/users", nil))
+	assert.Equal(t, []string{"global", "response"}, calls)
+	assert.Equal(t, http.StatusAccepted, recorder.Code)
+	assert.Equal(t, "/users", recorder.Header().Get("X-Request-Path"))
+	assert.JSONEq(t, `{"name":"alice"}`, recorder.Body.String())
+
+	t.Run("rewrite content", func(t *testing.T) {
+		response := New(http.StatusOK, "hello")
+		response.BeforeSend(func(response *Response, r *http.Request) {
+			response.SetContent("bye")
+		})
+		recorder := httptest.NewRecorder()
+		response.ServeHTTP(recorder, httptest.NewRequest("GET", "/", nil))
+		assert.Equal(t, "bye", recorder.Body.String())
+	})
+
+	t.Run("template error", func(t *testing.T) {
+		response := &HtmlResponse{Response: New(http.StatusOK)}
+		response.SetHTML(`{{template "missing"}}`)
+		response.BeforeSend(func(response *Response, r *http.Request) {
+			response.SetHeader("X-Hooked", "1")
+		})
+		recorder := httptest.NewRecorder()
+		response.ServeHTTP(recorder, httptest.NewRequest("GET", "/", nil))
+		assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+		assert.Equal(t, "1", recorder.Header().Get("X-Hooked"))
+		assert.Equal(t, "text/plain; charset=utf-8", recorder.Header().Get("Content-Type"))
+		assert.Contains(t, recorder.Body.String(), "missing")
+		assert.Equal(t, http.StatusOK, response.StatusCode())
+	})
+}
+
+func TestResponse_AfterSend(t *testing.T) {
+	defer ClearHooks()
+	var results []SendResult
+	AfterSend(func(result SendResult) {
+		results = append(results, result)
+	})
+
+	t.Run("json", func(t *testing.T) {
+		results = nil
+		response := New(http.StatusCreated).JSON(map[string]int{"id": 1})
+		request := httptest.NewRequest("POST", "/", nil)
+		response.ServeHTTP(httptest.NewRecorder(), request)
+		if assert.Len(t, results, 1) {
+			assert.Equal(t, response.Response, results[0].Response)
+			assert.Equal(t, request, results[0].Request)
+			assert.Equal(t, http.StatusCreated, results[0].StatusCode)
+			assert.Equal(t, int64(len(`{"id":1}`)), results[0].BytesWritten)
+			assert.Nil(t, results[0].Err)
+		}
+	})
+
+	t.Run("file", func(t *testing.T) {
+		results = nil
+		file := filepath.Join(t.TempDir(), "hello.txt")
+		assert.Nil(t, os.WriteFile(file, []byte("hello"), 0o644))
+		New(http.StatusOK).File(file).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+		if assert.Len(t, results, 1) {
+			assert.Equal(t, http.StatusOK, results[0].StatusCode)
+			assert.Equal(t, int64(5), results[0].BytesWritten)
+		}
+	})
+
+	t.Run("stream", func(t *testing.T) {
+		results = nil
+		response := New(http.StatusOK).Stream(func(w io.Writer) bool {
+			_, _ = w.Write([]byte("chunk"))
+			return false
+		})
+		response.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+		if assert.Len(t, results, 1) {
+			assert.Equal(t, http.StatusOK, results[0].StatusCode)
+			assert.Equal(t, int64(5), results[0].BytesWritten)
+		}
+	})
+
+	t.Run("panic", func(t *testing.T) {
+		results = nil
+		response := New(http.StatusOK).File(filepath.Join(t.TempDir(), "missing.txt"))
+		assert.Panics(t, func() {
+			response.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+		})
+		if assert.Len(t, results, 1) {
+			assert.True(t, errors.Is(results[0].Err, os.ErrNotExist))
+		}
+	})
+}
+
+func TestResponse_Terminate(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "report.csv")
+	assert.Nil(t, os.WriteFile(file, []byte("a,b"), 0o644))
+	terminated := make(chan SendResult, 1)
+	response := New(http.StatusOK).File(file).DeleteFileAfterSend()
+	response.Terminate(func(result SendResult) {
+		terminated <- result
+	})
+
+	// the request context of httptest is never cancelled
+	recorder := httptest.NewRecorder()
+	response.ServeHTTP(recorder, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, "a,b", recorder.Body.String())
+	select {
+	case result := <-terminated:
+		assert.Equal(t, int64(3), result.BytesWritten)
+	case <-time.After(time.Second):
+		t.Fatal("terminate callback did not run")
+	}
+	assert.Eventually(t, func() bool {
+		_, err := os.Stat(file)
+		return os.IsNotExist(err)
+	}, time.Second, time.Millisecond)
+}
+
+func TestHandler_Terminate(t *testing.T) {
+	terminated := make(chan SendResult, 1)
+	handler := Handle(func(r *http.Request) (http.Handler, error) {
+		response := New(http.StatusOK, "ok")
+		response.Terminate(func(result SendResult) {
+			terminated <- result
+		})
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			response.ServeHTTP(w, r)
+			// the callbacks wait for the handler which sent the response
+			select {
+			case <-terminated:
+				t.Error("terminate callback ran before the handler returned")
+			case <-time.After(50 * time.Millisecond):
+			}
+		}), nil
+	})
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, "ok", recorder.Body.String())
+	select {
+	case result := <-terminated:
+		assert.Equal(t, http.StatusOK, result.StatusCode)
+	case <-time.After(time.Second):
+		t.Fatal("terminate callback did not run")
+	}
+}
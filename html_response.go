@@ -35,12 +35,28 @@ func (h *HtmlResponse) Assign(key string, value any) {
 }
 
 func (h *HtmlResponse) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.send(w, r, h.serve)
+}
+
+func (h *HtmlResponse) serve(w http.ResponseWriter, r *http.Request) {
 	buf := new(bytes.Buffer)
-	tpl := template.Must(template.New("html").Parse(h.html))
+	tpl := template.Must(template.New("html").Funcs(template.FuncMap{
+		"cspNonce": func() string {
+			return CSPNonce(r)
+		},
+	}).Parse(h.html))
 	if err := tpl.Execute(buf, h.model); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		// the error is sent by a copy so the hooks and policies of the response still apply
+		response := *h.Response
+		response.headers = h.headers.Clone()
+		response.headers.Del("Content-Length")
+		response.SetHeader("Content-Type", "text/plain; charset=utf-8")
+		response.SetHeader("X-Content-Type-Options", "nosniff")
+		response.SetStatusCode(http.StatusInternalServerError)
+		response.SetContent(err.Error() + "\n")
+		response.serve(w, r)
 		return
 	}
 	h.Response.SetContent(buf.String())
-	h.Response.ServeHTTP(w, r)
+	h.Response.serve(w, r)
 }
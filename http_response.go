@@ -0,0 +1,75 @@
+package response
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+// hopByHopHeaders are the headers which only apply to a single connection
+var hopByHopHeaders = []string{
+	"Connection",
+	"Proxy-Connection",
+	"Keep-Alive",
+	"Proxy-Authenticate",
+	"Proxy-Authorization",
+	"Te",
+	"Trailer",
+	"Transfer-Encoding",
+	"Upgrade",
+}
+
+// FromHTTPResponse creates a [ReaderResponse] from an [http.Response], such as the reply of an upstream service.
+// The status code, the headers except hop-by-hop headers and the cookies are kept, and cookies can be edited
+// through Cookies. The body is streamed to the client when the response is sent and closed afterwards.
+func FromHTTPResponse(resp *http.Response) *ReaderResponse {
+	response := New(resp.StatusCode)
+	for key, values := range resp.Header {
+		if key == "Set-Cookie" {
+			continue
+		}
+		response.headers[key] = append([]string(nil), values...)
+	}
+	for _, key := range hopByHopHeaders {
+		response.headers.Del(key)
+	}
+	for _, cookie := range resp.Cookies() {
+		response.SetCookie(cookie)
+	}
+	reader := response.Reader(resp.Body)
+	reader.closer = resp.Body
+	if contentType := resp.Header.Get("Content-Type"); contentType != "" {
+		reader.SetContentType(contentType)
+	}
+	return reader
+}
+
+// ToHTTPResponse renders a response of any type into an [http.Response] as it would be sent for the request.
+// The body is fully rendered in memory, so the returned response does not need a connection.
+func ToHTTPResponse(response http.Handler, r *http.Request) *http.Response {
+	writer := newBufferedResponseWriter()
+	response.ServeHTTP(writer, r)
+	body := writer.body.Bytes()
+	header := writer.header
+	if header.Get("Content-Type") == "" && len(body) > 0 {
+		header.Set("Content-Type", http.DetectContentType(body))
+	}
+	contentLength := int64(len(body))
+	if value := header.Get("Content-Length"); value != "" {
+		if length, err := strconv.ParseInt(value, 10, 64); err == nil {
+			contentLength = length
+		}
+	}
+	return &http.Response{
+		Status:        strconv.Itoa(writer.statusCode) + " " + http.StatusText(writer.statusCode),
+		StatusCode:    writer.statusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: contentLength,
+		Request:       r,
+	}
+}
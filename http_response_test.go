@@ -0,0 +1,89 @@
+package response
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type trackingBody struct {
+	io.Reader
+	closed bool
+}
+
+func (body *trackingBody) Close() error {
+	body.closed = true
+	return nil
+}
+
+func TestFromHTTPResponse(t *testing.T) {
+	body := &trackingBody{Reader: strings.NewReader(`{"message":"upstream"}`)}
+	upstream := &http.Response{
+		StatusCode: 202,
+		Header: http.Header{
+			"Content-Type": {"application/json"},
+			"X-Upstream":   {"a", "b"},
+			"Connection":   {"keep-alive"},
+			"Set-Cookie":   {"session=abc; Path=/; HttpOnly", "theme=dark"},
+		},
+		Body: body,
+	}
+	response := FromHTTPResponse(upstream)
+	assert.Equal(t, 202, response.StatusCode())
+	assert.Len(t, response.Cookies(), 2)
+	response.Cookies()[0].Domain = "example.com"
+	response.SetHeader("X-Proxy", "1")
+
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest("GET", "/", nil)
+	response.ServeHTTP(recorder, request)
+
+	result := recorder.Result()
+	assert.Equal(t, 202, result.StatusCode)
+	assert.Equal(t, "application/json", result.Header.Get("Content-Type"))
+	assert.Equal(t, []string{"a", "b"}, result.Header.Values("X-Upstream"))
+	assert.Equal(t, "1", result.Header.Get("X-Proxy"))
+	assert.Empty(t, result.Header.Get("Connection"))
+	assert.Equal(t, []string{"session=abc; Path=/; Domain=example.com; HttpOnly", "theme=dark"}, result.Header.Values("Set-Cookie"))
+	assert.Equal(t, `{"message":"upstream"}`, recorder.Body.String())
+	assert.True(t, body.closed)
+}
+
+func TestFromHTTPResponse_DetectContentType(t *testing.T) {
+	upstream := &http.Response{
+		StatusCode: 200,
+		Header:     http.Header{},
+		Body:       io.NopCloser(strings.NewReader("Hello, World!")),
+	}
+	recorder := httptest.NewRecorder()
+	FromHTTPResponse(upstream).ServeHTTP(recorder, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, "text/plain; charset=utf-8", recorder.Header().Get("Content-Type"))
+	assert.Equal(t, "Hello, World!", recorder.Body.String())
+}
+
+func TestToHTTPResponse(t *testing.T) {
+	request := httptest.NewRequest("GET", "/", nil)
+	response := New(201).JSON(map[string]string{"message": "created"})
+	response.SetCookie(&http.Cookie{Name: "theme", Value: "dark"})
+
+	result := ToHTTPResponse(response, request)
+	assert.Equal(t, 201, result.StatusCode)
+	assert.Equal(t, "201 Created", result.Status)
+	assert.Equal(t, "application/json", result.Header.Get("Content-Type"))
+	assert.Equal(t, "dark", result.Cookies()[0].Value)
+	assert.Equal(t, int64(len(`{"message":"created"}`)), result.ContentLength)
+	assert.Same(t, request, result.Request)
+	body, err := io.ReadAll(result.Body)
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"message":"created"}`, string(body))
+
+	result = ToHTTPResponse(New(200, "<html><body>Hello</body></html>"), request)
+	assert.Equal(t, "text/html; charset=utf-8", result.Header.Get("Content-Type"))
+
+	result = ToHTTPResponse(New(302).Redirect("/login"), request)
+	assert.Equal(t, "/login", result.Header.Get("Location"))
+}
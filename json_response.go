@@ -1,15 +1,29 @@
 package response
 
 import (
+	"bytes"
 	"encoding/json"
 	"net/http"
 )
 
+// JSONOptions changes how JSON responses are encoded.
+//   - Prefix and Indent: When set, the JSON is indented as by [json.MarshalIndent].
+//   - DisableHTMLEscape: When true, <, > and & are not escaped in strings.
+type JSONOptions struct {
+	Prefix            string
+	Indent            string
+	DisableHTMLEscape bool
+}
+
 // JSONResponse provides a convenient way to send JSON-encoded data
 // as the response body in an HTTP request.
+// The fields which are sent can be selected by a [FieldMask], set with SetFieldMask or taken from the
+// query parameter of the request with FieldsFromQuery.
 type JSONResponse struct {
 	*Response
-	data any
+	data    any
+	options JSONOptions
+	fields  fieldSelection
 }
 
 // SetContent sets response content
@@ -17,14 +31,45 @@ func (jsonResponse *JSONResponse) SetContent(data any) {
 	jsonResponse.data = data
 }
 
+// SetOptions sets the encoding options
+func (jsonResponse *JSONResponse) SetOptions(options JSONOptions) *JSONResponse {
+	jsonResponse.options = options
+	return jsonResponse
+}
+
 // ServeHTTP implements the http.Handler interface and writes the
 // JSON-encoded response data to the ResponseWriter.
 func (jsonResponse *JSONResponse) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	jsonBytes, err := json.Marshal(jsonResponse.data)
-	if err != nil {
+	jsonResponse.send(w, r, jsonResponse.serve)
+}
+
+func (jsonResponse *JSONResponse) serve(w http.ResponseWriter, r *http.Request) {
+	data, problem := jsonResponse.fields.project(r, jsonResponse.data)
+	if problem != nil {
+		jsonResponse.serveProblem(w, r, problem)
+		return
+	}
+	buf := new(bytes.Buffer)
+	encoder := json.NewEncoder(buf)
+	encoder.SetIndent(jsonResponse.options.Prefix, jsonResponse.options.Indent)
+	encoder.SetEscapeHTML(!jsonResponse.options.DisableHTMLEscape)
+	if err := encoder.Encode(data); err != nil {
 		panic(err)
 	}
-	jsonResponse.content = jsonBytes
-	jsonResponse.SetHeader("content-type", "application/json")
-	jsonResponse.Response.ServeHTTP(w, r)
+	jsonResponse.content = bytes.TrimSuffix(buf.Bytes(), []byte("\n"))
+	if !jsonResponse.HasHeader("content-type") {
+		jsonResponse.SetHeader("content-type", "application/json")
+	}
+	jsonResponse.Response.serve(w, r)
+}
+
+// serveProblem sends the problem of a rejected field mask instead of the data
+func (jsonResponse *JSONResponse) serveProblem(w http.ResponseWriter, r *http.Request, problem *ProblemDetails) {
+	// the problem is sent by a copy so the response can still be served to other requests
+	response := *jsonResponse.Response
+	response.headers = jsonResponse.headers.Clone()
+	response.headers.Set("Content-Type", "application/problem+json")
+	response.statusCode = problem.Status
+	problem.Instance = r.URL.Path
+	(&JSONResponse{Response: &response, data: problem, options: jsonResponse.options}).serve(w, r)
 }
@@ -0,0 +1,533 @@
+package response
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gopi-frame/exception"
+)
+
+// JSONAPIMediaType is the media type of JSON:API documents
+const JSONAPIMediaType = "application/vnd.api+json"
+
+// JSONAPIResourceIdentifier identifies a resource in the relationships of another one
+type JSONAPIResourceIdentifier struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+}
+
+// JSONAPIRelationship is a relationship of a resource, Data is a [JSONAPIResourceIdentifier] for to-one
+// relationships, a list of them for to-many relationships, or nil for an empty to-one relationship
+type JSONAPIRelationship struct {
+	Data  any               `json:"data"`
+	Links map[string]string `json:"links,omitempty"`
+	Meta  map[string]any    `json:"meta,omitempty"`
+}
+
+// JSONAPIResource is a resource object of a JSON:API document
+type JSONAPIResource struct {
+	Type          string                          `json:"type"`
+	ID            string                          `json:"id"`
+	Attributes    map[string]any                  `json:"attributes,omitempty"`
+	Relationships map[string]*JSONAPIRelationship `json:"relationships,omitempty"`
+	Links         map[string]string               `json:"links,omitempty"`
+	Meta          map[string]any                  `json:"meta,omitempty"`
+}
+
+// JSONAPIErrorSource is the part of the request an error is caused by
+type JSONAPIErrorSource struct {
+	Pointer   string `json:"pointer,omitempty"`
+	Parameter string `json:"parameter,omitempty"`
+	Header    string `json:"header,omitempty"`
+}
+
+// JSONAPIError is an error object of a JSON:API document
+type JSONAPIError struct {
+	ID     string              `json:"id,omitempty"`
+	Status string              `json:"status,omitempty"`
+	Code   string              `json:"code,omitempty"`
+	Title  string              `json:"title,omitempty"`
+	Detail string              `json:"detail,omitempty"`
+	Source *JSONAPIErrorSource `json:"source,omitempty"`
+	Links  map[string]string   `json:"links,omitempty"`
+	Meta   map[string]any      `json:"meta,omitempty"`
+}
+
+// NewJSONAPIError creates a [JSONAPIError] of the status, titled by the status text
+func NewJSONAPIError(statusCode int, detail string) *JSONAPIError {
+	return &JSONAPIError{
+		Status: strconv.Itoa(statusCode),
+		Title:  http.StatusText(statusCode),
+		Detail: detail,
+	}
+}
+
+// JSONAPILinker is implemented by resources which have links
+type JSONAPILinker interface {
+	JSONAPILinks() map[string]string
+}
+
+// JSONAPIMetaProvider is implemented by resources which have meta
+type JSONAPIMetaProvider interface {
+	JSONAPIMeta() map[string]any
+}
+
+// JSONAPIResponse is used to send JSON:API documents.
+// The data is a struct, a pointer to a struct or a list of them, whose fields are annotated with jsonapi tags:
+//   - `jsonapi:"primary,<type>"`: The id of the resource, a string or an integer, and the type of the resource.
+//   - `jsonapi:"attr,<name>[,omitempty]"`: An attribute.
+//   - `jsonapi:"relation,<name>[,omitempty]"`: A relationship, a struct or a pointer to one for to-one relationships,
+//     a list of them for to-many relationships.
+//
+// Resources implementing [JSONAPILinker] and [JSONAPIMetaProvider] get links and meta.
+// The relationships listed by the include query parameter, or by Include when the request has none, are sent in
+// the "included" member, and the fields[<type>] query parameters select the fields of each type.
+// A request whose Accept header only lists the JSON:API media type with parameters other than profile is answered
+// with 406 Not Acceptable, no extension is supported so an ext parameter counts as well. An include path which is
+// not a relationship is answered with 400 Bad Request, as the specification requires.
+type JSONAPIResponse struct {
+	*JSONResponse
+	data      any
+	includes  []string
+	fieldsets map[string][]string
+	links     map[string]string
+	meta      map[string]any
+	errors    []*JSONAPIError
+}
+
+// SetContent sets the primary data
+func (jsonAPIResponse *JSONAPIResponse) SetContent(data any) {
+	jsonAPIResponse.data = data
+}
+
+// Include sets the relationship paths which are included when the request has no include query parameter,
+// e.g. "author" or "comments.author"
+func (jsonAPIResponse *JSONAPIResponse) Include(paths ...string) *JSONAPIResponse {
+	jsonAPIResponse.includes = paths
+	return jsonAPIResponse
+}
+
+// SetFields sets the fields of the resources of the type which are sent,
+// a fields[<type>] query parameter of the request takes precedence
+func (jsonAPIResponse *JSONAPIResponse) SetFields(resourceType string, fields ...string) *JSONAPIResponse {
+	if jsonAPIResponse.fieldsets == nil {
+		jsonAPIResponse.fieldsets = make(map[string][]string)
+	}
+	jsonAPIResponse.fieldsets[resourceType] = fields
+	return jsonAPIResponse
+}
+
+// SetLinks sets the top-level links, a self link to the request URL is added when it has none
+func (jsonAPIResponse *JSONAPIResponse) SetLinks(links map[string]string) *JSONAPIResponse {
+	jsonAPIResponse.links = links
+	return jsonAPIResponse
+}
+
+// SetMeta sets the top-level meta
+func (jsonAPIResponse *JSONAPIResponse) SetMeta(meta map[string]any) *JSONAPIResponse {
+	jsonAPIResponse.meta = meta
+	return jsonAPIResponse
+}
+
+// AddError adds error objects, a document with errors is sent without data
+func (jsonAPIResponse *JSONAPIResponse) AddError(errors ...*JSONAPIError) *JSONAPIResponse {
+	jsonAPIResponse.errors = append(jsonAPIResponse.errors, errors...)
+	return jsonAPIResponse
+}
+
+// ServeHTTP sends the document
+func (jsonAPIResponse *JSONAPIResponse) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	jsonAPIResponse.send(w, r, jsonAPIResponse.serve)
+}
+
+func (jsonAPIResponse *JSONAPIResponse) serve(w http.ResponseWriter, r *http.Request) {
+	// the document is sent by a copy so the response can still be served to other requests
+	response := *jsonAPIResponse.Response
+	response.headers = jsonAPIResponse.headers.Clone()
+	response.Vary("Accept")
+	if !response.HasHeader("Content-Type") {
+		response.SetHeader("Content-Type", JSONAPIMediaType)
+	}
+	document, errorStatus := jsonAPIResponse.document(r)
+	if errorStatus != 0 {
+		response.statusCode = errorStatus
+	}
+	(&JSONResponse{Response: &response, data: document, options: jsonAPIResponse.options}).serve(w, r)
+}
+
+// document returns the document for the request, and the status of the error document sent in its place
+func (jsonAPIResponse *JSONAPIResponse) document(r *http.Request) (map[string]any, int) {
+	links := map[string]string{"self": requestURL(r)}
+	for rel, href := range jsonAPIResponse.links {
+		links[rel] = href
+	}
+	document := map[string]any{
+		"jsonapi": map[string]string{"version": "1.1"},
+		"links":   links,
+	}
+	if len(jsonAPIResponse.meta) > 0 {
+		document["meta"] = jsonAPIResponse.meta
+	}
+	fail := func(jsonAPIError *JSONAPIError) (map[string]any, int) {
+		delete(document, "meta")
+		document["errors"] = []*JSONAPIError{jsonAPIError}
+		statusCode, _ := strconv.Atoi(jsonAPIError.Status)
+		return document, statusCode
+	}
+	if !jsonAPIAcceptable(r.Header.Get("Accept")) {
+		jsonAPIError := NewJSONAPIError(http.StatusNotAcceptable, "The JSON:API media type is only accepted with parameters which are not supported.")
+		jsonAPIError.Source = &JSONAPIErrorSource{Header: "Accept"}
+		return fail(jsonAPIError)
+	}
+	if len(jsonAPIResponse.errors) > 0 {
+		document["errors"] = jsonAPIResponse.errors
+		return document, 0
+	}
+
+	includes := jsonAPIResponse.includes
+	if query := r.URL.Query(); query.Has("include") {
+		includes = nil
+		for _, path := range strings.Split(query.Get("include"), ",") {
+			if path = strings.TrimSpace(path); path != "" {
+				includes = append(includes, path)
+			}
+		}
+	}
+	if elementType := jsonAPIElementType(reflect.TypeOf(jsonAPIResponse.data)); elementType != nil {
+		for _, path := range includes {
+			if !jsonAPIIncludable(elementType, strings.Split(path, ".")) {
+				jsonAPIError := NewJSONAPIError(http.StatusBadRequest, fmt.Sprintf("The relationship path `%s` can not be included.", path))
+				jsonAPIError.Source = &JSONAPIErrorSource{Parameter: "include"}
+				return fail(jsonAPIError)
+			}
+		}
+	}
+
+	encoder := &jsonAPIEncoder{fields: jsonAPIResponse.requestFields(r), seen: make(map[JSONAPIResourceIdentifier]bool)}
+	data := reflect.ValueOf(jsonAPIResponse.data)
+	for data.Kind() == reflect.Pointer && !data.IsNil() && data.Type() != reflect.TypeOf(&JSONAPIResource{}) {
+		data = data.Elem()
+	}
+	var primary []reflect.Value
+	switch {
+	case !data.IsValid() || data.Kind() == reflect.Pointer && data.IsNil():
+		document["data"] = nil
+	case data.Kind() == reflect.Slice || data.Kind() == reflect.Array:
+		resources := make([]*JSONAPIResource, 0, data.Len())
+		for i := 0; i < data.Len(); i++ {
+			resource, value := encoder.resource(data.Index(i))
+			// a nil entry is not a resource, the array of primary data only holds resource objects
+			if resource == nil {
+				continue
+			}
+			encoder.seen[JSONAPIResourceIdentifier{Type: resource.Type, ID: resource.ID}] = true
+			resources = append(resources, resource)
+			primary = append(primary, value)
+		}
+		document["data"] = resources
+	default:
+		resource, value := encoder.resource(data)
+		if resource == nil {
+			document["data"] = nil
+			break
+		}
+		encoder.seen[JSONAPIResourceIdentifier{Type: resource.Type, ID: resource.ID}] = true
+		document["data"] = resource
+		primary = append(primary, value)
+	}
+	for _, path := range includes {
+		for _, value := range primary {
+			encoder.include(value, strings.Split(path, "."))
+		}
+	}
+	if len(encoder.included) > 0 {
+		document["included"] = encoder.included
+	}
+	return document, 0
+}
+
+// requestFields returns the sparse fieldsets of the request, which take precedence over the ones set on the response
+func (jsonAPIResponse *JSONAPIResponse) requestFields(r *http.Request) map[string][]string {
+	fields := make(map[string][]string, len(jsonAPIResponse.fieldsets))
+	for resourceType, names := range jsonAPIResponse.fieldsets {
+		fields[resourceType] = names
+	}
+	for key, values := range r.URL.Query() {
+		resourceType, ok := strings.CutPrefix(key, "fields[")
+		if !ok || !strings.HasSuffix(resourceType, "]") || len(values) == 0 {
+			continue
+		}
+		names := make([]string, 0)
+		for _, name := range strings.Split(values[0], ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, name)
+			}
+		}
+		fields[strings.TrimSuffix(resourceType, "]")] = names
+	}
+	return fields
+}
+
+// jsonAPIAcceptable returns if the Accept header allows a JSON:API document without extensions:
+// it does not list the JSON:API media type, or lists it at least once without parameters other than profile and with q above 0
+func jsonAPIAcceptable(accept string) bool {
+	listed := false
+	for _, accepted := range parseAccept(accept) {
+		if accepted.mediaType != JSONAPIMediaType {
+			continue
+		}
+		listed = true
+		// q=0 means the range is not acceptable
+		acceptable := accepted.quality > 0
+		for _, param := range accepted.params {
+			if name, _, _ := strings.Cut(param, "="); !strings.EqualFold(strings.TrimSpace(name), "profile") {
+				acceptable = false
+			}
+		}
+		if acceptable {
+			return true
+		}
+	}
+	return !listed
+}
+
+// jsonAPIModel is the jsonapi tags of a struct type
+type jsonAPIModel struct {
+	resourceType string
+	id           int
+	attributes   []jsonAPIField
+	relations    []jsonAPIField
+}
+
+// jsonAPIField is an attribute or a relationship of a resource
+type jsonAPIField struct {
+	name      string
+	index     int
+	omitEmpty bool
+	many      bool
+}
+
+var jsonAPIModels sync.Map
+
+// jsonAPIModelOf returns the model of the struct type, it panics when the type has no primary field
+func jsonAPIModelOf(t reflect.Type) *jsonAPIModel {
+	if model, ok := jsonAPIModels.Load(t); ok {
+		return model.(*jsonAPIModel)
+	}
+	model := &jsonAPIModel{id: -1}
+	for i := 0; i < t.NumField(); i++ {
+		tag, ok := t.Field(i).Tag.Lookup("jsonapi")
+		if !ok {
+			continue
+		}
+		options := strings.Split(tag, ",")
+		if len(options) < 2 {
+			continue
+		}
+		field := jsonAPIField{name: options[1], index: i, omitEmpty: len(options) > 2 && options[2] == "omitempty"}
+		switch options[0] {
+		case "primary":
+			model.resourceType = options[1]
+			model.id = i
+		case "attr":
+			model.attributes = append(model.attributes, field)
+		case "relation":
+			kind := t.Field(i).Type.Kind()
+			field.many = kind == reflect.Slice || kind == reflect.Array
+			model.relations = append(model.relations, field)
+		}
+	}
+	if model.id < 0 {
+		panic(exception.NewArgumentException("data", t.String(), fmt.Sprintf("type `%s` has no jsonapi primary field", t)))
+	}
+	stored, _ := jsonAPIModels.LoadOrStore(t, model)
+	return stored.(*jsonAPIModel)
+}
+
+// relation returns the relationship of the model
+func (model *jsonAPIModel) relation(name string) (jsonAPIField, bool) {
+	for _, relation := range model.relations {
+		if relation.name == name {
+			return relation, true
+		}
+	}
+	return jsonAPIField{}, false
+}
+
+// jsonAPIElementType returns the struct type of the resources of the data, nil when it has none
+func jsonAPIElementType(t reflect.Type) reflect.Type {
+	for t != nil {
+		switch t.Kind() {
+		case reflect.Pointer, reflect.Slice, reflect.Array:
+			t = t.Elem()
+		case reflect.Struct:
+			if t == reflect.TypeOf(JSONAPIResource{}) {
+				return nil
+			}
+			return t
+		default:
+			return nil
+		}
+	}
+	return nil
+}
+
+// jsonAPIIncludable returns if the path is a chain of relationships from the struct type
+func jsonAPIIncludable(t reflect.Type, path []string) bool {
+	if len(path) == 0 {
+		return true
+	}
+	relation, ok := jsonAPIModelOf(t).relation(path[0])
+	if !ok {
+		return false
+	}
+	related := jsonAPIElementType(t.Field(relation.index).Type)
+	return related != nil && jsonAPIIncludable(related, path[1:])
+}
+
+// jsonAPIEncoder encodes the resources of a document
+type jsonAPIEncoder struct {
+	fields   map[string][]string
+	seen     map[JSONAPIResourceIdentifier]bool
+	included []*JSONAPIResource
+}
+
+// resource returns the resource object of the value and the struct it is made of, nil for a nil value
+func (encoder *jsonAPIEncoder) resource(value reflect.Value) (*JSONAPIResource, reflect.Value) {
+	for value.Kind() == reflect.Pointer || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return nil, reflect.Value{}
+		}
+		if resource, ok := value.Interface().(*JSONAPIResource); ok {
+			return resource, reflect.Value{}
+		}
+		value = value.Elem()
+	}
+	if resource, ok := value.Interface().(JSONAPIResource); ok {
+		return &resource, reflect.Value{}
+	}
+	model := jsonAPIModelOf(value.Type())
+	resource := &JSONAPIResource{Type: model.resourceType, ID: jsonAPIID(value.Field(model.id))}
+	fields, sparse := encoder.fields[model.resourceType]
+	selected := func(name string) bool {
+		if !sparse {
+			return true
+		}
+		for _, field := range fields {
+			if field == name {
+				return true
+			}
+		}
+		return false
+	}
+	for _, attribute := range model.attributes {
+		fieldValue := value.Field(attribute.index)
+		if !selected(attribute.name) || attribute.omitEmpty && isEmptyJSONValue(fieldValue) {
+			continue
+		}
+		if resource.Attributes == nil {
+			resource.Attributes = make(map[string]any)
+		}
+		resource.Attributes[attribute.name] = fieldValue.Interface()
+	}
+	for _, relation := range model.relations {
+		fieldValue := value.Field(relation.index)
+		if !selected(relation.name) || relation.omitEmpty && isEmptyJSONValue(fieldValue) {
+			continue
+		}
+		if resource.Relationships == nil {
+			resource.Relationships = make(map[string]*JSONAPIRelationship)
+		}
+		relationship := &JSONAPIRelationship{}
+		if relation.many {
+			identifiers := make([]JSONAPIResourceIdentifier, 0, fieldValue.Len())
+			for i := 0; i < fieldValue.Len(); i++ {
+				if identifier, ok := jsonAPIIdentifier(fieldValue.Index(i)); ok {
+					identifiers = append(identifiers, identifier)
+				}
+			}
+			relationship.Data = identifiers
+		} else if identifier, ok := jsonAPIIdentifier(fieldValue); ok {
+			relationship.Data = identifier
+		}
+		resource.Relationships[relation.name] = relationship
+	}
+	source := value.Interface()
+	if value.CanAddr() {
+		source = value.Addr().Interface()
+	}
+	if linker, ok := source.(JSONAPILinker); ok {
+		resource.Links = linker.JSONAPILinks()
+	}
+	if provider, ok := source.(JSONAPIMetaProvider); ok {
+		resource.Meta = provider.JSONAPIMeta()
+	}
+	return resource, value
+}
+
+// include adds the resources at the relationship path of the struct to the included resources
+func (encoder *jsonAPIEncoder) include(value reflect.Value, path []string) {
+	if !value.IsValid() || len(path) == 0 {
+		return
+	}
+	relation, ok := jsonAPIModelOf(value.Type()).relation(path[0])
+	if !ok {
+		return
+	}
+	fieldValue := value.Field(relation.index)
+	var related []reflect.Value
+	if relation.many {
+		for i := 0; i < fieldValue.Len(); i++ {
+			related = append(related, fieldValue.Index(i))
+		}
+	} else {
+		related = append(related, fieldValue)
+	}
+	for _, item := range related {
+		for item.Kind() == reflect.Pointer || item.Kind() == reflect.Interface {
+			if item.IsNil() {
+				break
+			}
+			item = item.Elem()
+		}
+		if item.Kind() != reflect.Struct {
+			continue
+		}
+		identifier, _ := jsonAPIIdentifier(item)
+		if !encoder.seen[identifier] {
+			encoder.seen[identifier] = true
+			resource, _ := encoder.resource(item)
+			encoder.included = append(encoder.included, resource)
+		}
+		encoder.include(item, path[1:])
+	}
+}
+
+// jsonAPIIdentifier returns the identifier of a related struct, false when the relation is nil
+func jsonAPIIdentifier(value reflect.Value) (JSONAPIResourceIdentifier, bool) {
+	for value.Kind() == reflect.Pointer || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return JSONAPIResourceIdentifier{}, false
+		}
+		value = value.Elem()
+	}
+	model := jsonAPIModelOf(value.Type())
+	return JSONAPIResourceIdentifier{Type: model.resourceType, ID: jsonAPIID(value.Field(model.id))}, true
+}
+
+// jsonAPIID formats the id of a resource
+func jsonAPIID(value reflect.Value) string {
+	switch value.Kind() {
+	case reflect.String:
+		return value.String()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(value.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(value.Uint(), 10)
+	}
+	return fmt.Sprint(value.Interface())
+}
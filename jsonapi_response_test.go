@@ -0,0 +1,163 @@
+package response
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type jsonAPIPerson struct {
+	ID   int    `jsonapi:"primary,people"`
+	Name string `jsonapi:"attr,name"`
+	Bio  string `jsonapi:"attr,bio,omitempty"`
+}
+
+type jsonAPIComment struct {
+	ID     string         `jsonapi:"primary,comments"`
+	Body   string         `jsonapi:"attr,body"`
+	Author *jsonAPIPerson `jsonapi:"relation,author"`
+}
+
+type jsonAPIArticle struct {
+	ID       int               `jsonapi:"primary,articles"`
+	Title    string            `jsonapi:"attr,title"`
+	Internal string            `json:"internal"`
+	Author   *jsonAPIPerson    `jsonapi:"relation,author"`
+	Comments []*jsonAPIComment `jsonapi:"relation,comments"`
+}
+
+func (article *jsonAPIArticle) JSONAPILinks() map[string]string {
+	return map[string]string{"self": "/articles/1"}
+}
+
+func newJSONAPIArticle() *jsonAPIArticle {
+	alice := &jsonAPIPerson{ID: 9, Name: "Alice"}
+	return &jsonAPIArticle{
+		ID:       1,
+		Title:    "JSON:API",
+		Internal: "hidden",
+		Author:   alice,
+		Comments: []*jsonAPIComment{
+			{ID: "5", Body: "First", Author: &jsonAPIPerson{ID: 2, Name: "Bob", Bio: "Reader"}},
+			{ID: "12", Body: "Second", Author: alice},
+		},
+	}
+}
+
+func serveJSONAPI(response http.Handler, target string, accept string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest("GET", target, nil)
+	if accept != "" {
+		request.Header.Set("Accept", accept)
+	}
+	recorder := httptest.NewRecorder()
+	response.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func decodeJSONAPIDocument(t *testing.T, recorder *httptest.ResponseRecorder) map[string]json.RawMessage {
+	var document map[string]json.RawMessage
+	assert.Nil(t, json.Unmarshal(recorder.Body.Bytes(), &document))
+	return document
+}
+
+func TestJSONAPIResponse_Compound(t *testing.T) {
+	recorder := serveJSONAPI(New(http.StatusOK).JSONAPI(newJSONAPIArticle()).Include("author", "comments.author").
+		SetMeta(map[string]any{"copyright": "Example"}), "/articles/1", JSONAPIMediaType)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, JSONAPIMediaType, recorder.Header().Get("Content-Type"))
+	assert.Equal(t, "Accept", recorder.Header().Get("Vary"))
+	assert.JSONEq(t, `{
+		"jsonapi": {"version": "1.1"},
+		"links": {"self": "http://example.com/articles/1"},
+		"meta": {"copyright": "Example"},
+		"data": {
+			"type": "articles", "id": "1",
+			"attributes": {"title": "JSON:API"},
+			"relationships": {
+				"author": {"data": {"type": "people", "id": "9"}},
+				"comments": {"data": [{"type": "comments", "id": "5"}, {"type": "comments", "id": "12"}]}
+			},
+			"links": {"self": "/articles/1"}
+		},
+		"included": [
+			{"type": "people", "id": "9", "attributes": {"name": "Alice"}},
+			{"type": "comments", "id": "5", "attributes": {"body": "First"}, "relationships": {"author": {"data": {"type": "people", "id": "2"}}}},
+			{"type": "people", "id": "2", "attributes": {"name": "Bob", "bio": "Reader"}},
+			{"type": "comments", "id": "12", "attributes": {"body": "Second"}, "relationships": {"author": {"data": {"type": "people", "id": "9"}}}}
+		]
+	}`, recorder.Body.String())
+}
+
+func TestJSONAPIResponse_QueryParameters(t *testing.T) {
+	response := New(http.StatusOK).JSONAPI([]*jsonAPIArticle{newJSONAPIArticle()}).Include("comments")
+	recorder := serveJSONAPI(response, "/articles?include=author&fields[articles]=title,author&fields[people]=", "")
+	assert.JSONEq(t, `{
+		"jsonapi": {"version": "1.1"},
+		"links": {"self": "http://example.com/articles?include=author&fields[articles]=title,author&fields[people]="},
+		"data": [{
+			"type": "articles", "id": "1",
+			"attributes": {"title": "JSON:API"},
+			"relationships": {"author": {"data": {"type": "people", "id": "9"}}},
+			"links": {"self": "/articles/1"}
+		}],
+		"included": [{"type": "people", "id": "9"}]
+	}`, recorder.Body.String())
+
+	recorder = serveJSONAPI(response, "/articles?include=author.articles", "")
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	assert.JSONEq(t, `[{"status":"400","title":"Bad Request","detail":"The relationship path `+"`author.articles`"+` can not be included.","source":{"parameter":"include"}}]`,
+		string(decodeJSONAPIDocument(t, recorder)["errors"]))
+}
+
+func TestJSONAPIResponse_EmptyData(t *testing.T) {
+	var article *jsonAPIArticle
+	recorder := serveJSONAPI(New(http.StatusOK).JSONAPI(article), "/articles/2", "")
+	assert.JSONEq(t, `null`, string(decodeJSONAPIDocument(t, recorder)["data"]))
+
+	recorder = serveJSONAPI(New(http.StatusOK).JSONAPI([]jsonAPIArticle{}), "/articles", "")
+	assert.JSONEq(t, `[]`, string(decodeJSONAPIDocument(t, recorder)["data"]))
+
+	recorder = serveJSONAPI(New(http.StatusOK).JSONAPI([]*jsonAPIPerson{{ID: 1, Name: "Alice"}, nil}), "/people", "")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.JSONEq(t, `[{"type": "people", "id": "1", "attributes": {"name": "Alice"}}]`, string(decodeJSONAPIDocument(t, recorder)["data"]))
+}
+
+func TestJSONAPIResponse_Errors(t *testing.T) {
+	jsonAPIError := NewJSONAPIError(http.StatusUnprocessableEntity, "Title must not be empty.")
+	jsonAPIError.Source = &JSONAPIErrorSource{Pointer: "/data/attributes/title"}
+	recorder := serveJSONAPI(New(http.StatusUnprocessableEntity).JSONAPI(nil).AddError(jsonAPIError), "/articles", "")
+	assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+	document := decodeJSONAPIDocument(t, recorder)
+	assert.NotContains(t, document, "data")
+	assert.JSONEq(t, `[{"status":"422","title":"Unprocessable Entity","detail":"Title must not be empty.","source":{"pointer":"/data/attributes/title"}}]`,
+		string(document["errors"]))
+}
+
+func TestJSONAPIResponse_Accept(t *testing.T) {
+	cases := map[string]int{
+		"":                 http.StatusOK,
+		"application/json": http.StatusOK,
+		JSONAPIMediaType:   http.StatusOK,
+		JSONAPIMediaType + `; profile="https://example.com/timestamps"`: http.StatusOK,
+		JSONAPIMediaType + "; q=0.9; charset=utf-8":                     http.StatusOK,
+		JSONAPIMediaType + `; ext="https://example.com/atomic"`:         http.StatusNotAcceptable,
+		JSONAPIMediaType + "; charset=utf-8, " + JSONAPIMediaType:       http.StatusOK,
+		JSONAPIMediaType + "; charset=utf-8, application/json":          http.StatusNotAcceptable,
+		JSONAPIMediaType + "; q=0":                                      http.StatusNotAcceptable,
+		JSONAPIMediaType + "; q=0, " + JSONAPIMediaType + "; q=0.5":     http.StatusOK,
+	}
+	for accept, status := range cases {
+		recorder := serveJSONAPI(New(http.StatusOK).JSONAPI(&jsonAPIPerson{ID: 1}), "/people/1", accept)
+		assert.Equal(t, status, recorder.Code, accept)
+		assert.Equal(t, JSONAPIMediaType, recorder.Header().Get("Content-Type"), accept)
+	}
+}
+
+func TestJSONAPIResponse_MissingPrimary(t *testing.T) {
+	assert.Panics(t, func() {
+		serveJSONAPI(New(http.StatusOK).JSONAPI(struct{ Name string }{}), "/", "")
+	})
+}
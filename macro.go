@@ -0,0 +1,131 @@
+package response
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+
+	"github.com/gopi-frame/exception"
+)
+
+var globalMacros = newMacroRegistry()
+
+// MacroFunc builds a response from the arguments of the call using the factory of the call
+type MacroFunc func(factory *Factory, args ...any) http.Handler
+
+// MacroInfo describes a registered macro
+type MacroInfo struct {
+	Name        string
+	Description string
+}
+
+// RegisterMacro registers a named response builder for all factories, it panics when the name is taken.
+// Macros should be registered at startup.
+func RegisterMacro(name string, macro MacroFunc, description ...string) {
+	globalMacros.register(name, macro, description...)
+}
+
+// HasMacro returns if a global macro is registered with the name
+func HasMacro(name string) bool {
+	_, ok := globalMacros.get(name)
+	return ok
+}
+
+// CallMacro builds a response with the global macro using a factory with the default configuration
+func CallMacro(name string, args ...any) http.Handler {
+	return NewFactory().Macro(name, args...)
+}
+
+// Macros returns the global macros sorted by name
+func Macros() []MacroInfo {
+	return globalMacros.list()
+}
+
+// RegisterMacro registers a named response builder for the factory, it takes precedence over a global macro
+// with the same name and panics when the name is taken by another macro of the factory.
+func (factory *Factory) RegisterMacro(name string, macro MacroFunc, description ...string) *Factory {
+	factory.macros.register(name, macro, description...)
+	return factory
+}
+
+// HasMacro returns if a macro of the factory or a global macro is registered with the name
+func (factory *Factory) HasMacro(name string) bool {
+	_, ok := factory.macros.get(name)
+	return ok || HasMacro(name)
+}
+
+// Macro builds a response with the named macro of the factory or the global one, it panics when there is none
+func (factory *Factory) Macro(name string, args ...any) http.Handler {
+	entry, ok := factory.macros.get(name)
+	if !ok {
+		if entry, ok = globalMacros.get(name); !ok {
+			panic(exception.NewArgumentException("name", name, "Undefined response macro: "+name))
+		}
+	}
+	return entry.macro(factory, args...)
+}
+
+// Macros returns the macros of the factory and the global macros sorted by name
+func (factory *Factory) Macros() []MacroInfo {
+	infos := factory.macros.list()
+	for _, info := range globalMacros.list() {
+		if _, ok := factory.macros.get(info.Name); !ok {
+			infos = append(infos, info)
+		}
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Name < infos[j].Name
+	})
+	return infos
+}
+
+type macroEntry struct {
+	macro       MacroFunc
+	description string
+}
+
+// macroRegistry is a concurrency safe set of named macros
+type macroRegistry struct {
+	mu     sync.RWMutex
+	macros map[string]macroEntry
+}
+
+func newMacroRegistry() *macroRegistry {
+	return &macroRegistry{macros: make(map[string]macroEntry)}
+}
+
+func (registry *macroRegistry) register(name string, macro MacroFunc, description ...string) {
+	if name == "" || macro == nil {
+		panic(exception.NewArgumentException("name", name, "Invalid response macro"))
+	}
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	if _, ok := registry.macros[name]; ok {
+		panic(exception.NewArgumentException("name", name, "Response macro already registered: "+name))
+	}
+	entry := macroEntry{macro: macro}
+	if len(description) > 0 {
+		entry.description = description[0]
+	}
+	registry.macros[name] = entry
+}
+
+func (registry *macroRegistry) get(name string) (macroEntry, bool) {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+	entry, ok := registry.macros[name]
+	return entry, ok
+}
+
+func (registry *macroRegistry) list() []MacroInfo {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+	infos := make([]MacroInfo, 0, len(registry.macros))
+	for name, entry := range registry.macros {
+		infos = append(infos, MacroInfo{Name: name, Description: entry.description})
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Name < infos[j].Name
+	})
+	return infos
+}
@@ -0,0 +1,70 @@
+package response
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func withGlobalMacros(t *testing.T) {
+	previous := globalMacros
+	globalMacros = newMacroRegistry()
+	t.Cleanup(func() {
+		globalMacros = previous
+	})
+}
+
+func success(factory *Factory, args ...any) http.Handler {
+	return factory.JSON(http.StatusOK, map[string]any{"success": true, "data": args[0]})
+}
+
+func TestRegisterMacro(t *testing.T) {
+	withGlobalMacros(t)
+	RegisterMacro("success", success, "Wraps data in a success envelope")
+	assert.True(t, HasMacro("success"))
+	assert.False(t, HasMacro("missing"))
+	assert.Equal(t, []MacroInfo{{Name: "success", Description: "Wraps data in a success envelope"}}, Macros())
+
+	recorder := httptest.NewRecorder()
+	CallMacro("success", []int{1, 2}).ServeHTTP(recorder, httptest.NewRequest("GET", "/", nil))
+	assert.JSONEq(t, `{"success":true,"data":[1,2]}`, recorder.Body.String())
+
+	assert.Panics(t, func() {
+		RegisterMacro("success", success)
+	})
+	assert.Panics(t, func() {
+		CallMacro("missing")
+	})
+}
+
+func TestFactory_Macro(t *testing.T) {
+	withGlobalMacros(t)
+	RegisterMacro("success", success)
+	RegisterMacro("csv", func(factory *Factory, args ...any) http.Handler {
+		response := factory.Text(http.StatusOK, "a,b\n")
+		response.SetHeader("Content-Type", "text/csv")
+		return response
+	}, "CSV download")
+
+	factory := NewFactory().SetHeader("X-App", "demo").
+		RegisterMacro("success", func(factory *Factory, args ...any) http.Handler {
+			return factory.JSON(http.StatusOK, map[string]any{"ok": args[0]})
+		}, "Factory success")
+	assert.True(t, factory.HasMacro("csv"))
+	assert.Equal(t, []MacroInfo{
+		{Name: "csv", Description: "CSV download"},
+		{Name: "success", Description: "Factory success"},
+	}, factory.Macros())
+
+	recorder := httptest.NewRecorder()
+	factory.Macro("success", "yes").ServeHTTP(recorder, httptest.NewRequest("GET", "/", nil))
+	assert.JSONEq(t, `{"ok":"yes"}`, recorder.Body.String())
+	assert.Equal(t, "demo", recorder.Header().Get("X-App"))
+
+	recorder = httptest.NewRecorder()
+	factory.Macro("csv").ServeHTTP(recorder, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, "text/csv", recorder.Header().Get("Content-Type"))
+	assert.Equal(t, "demo", recorder.Header().Get("X-App"))
+}
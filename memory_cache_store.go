@@ -0,0 +1,90 @@
+package response
+
+import (
+	"container/list"
+	"sync"
+)
+
+// MemoryCacheStore is an in-memory [CacheStore] which evicts the least recently used entries
+// when the total size of the entries exceeds its limit
+type MemoryCacheStore struct {
+	mu       sync.Mutex
+	maxBytes int64
+	size     int64
+	items    map[string]*list.Element
+	lru      *list.List
+}
+
+type memoryCacheItem struct {
+	key   string
+	entry *CacheEntry
+	size  int64
+}
+
+// NewMemoryCacheStore creates a new [MemoryCacheStore] holding at most maxBytes of entries
+func NewMemoryCacheStore(maxBytes int64) *MemoryCacheStore {
+	return &MemoryCacheStore{
+		maxBytes: maxBytes,
+		items:    make(map[string]*list.Element),
+		lru:      list.New(),
+	}
+}
+
+// Get returns the entry stored under key and marks it as recently used
+func (store *MemoryCacheStore) Get(key string) (*CacheEntry, bool) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	element, ok := store.items[key]
+	if !ok {
+		return nil, false
+	}
+	store.lru.MoveToFront(element)
+	return element.Value.(*memoryCacheItem).entry, true
+}
+
+// Set stores the entry under key, an entry larger than the limit is not stored
+func (store *MemoryCacheStore) Set(key string, entry *CacheEntry) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	store.remove(key)
+	size := entry.Size() + int64(len(key))
+	if size > store.maxBytes {
+		return
+	}
+	store.items[key] = store.lru.PushFront(&memoryCacheItem{key: key, entry: entry, size: size})
+	store.size += size
+	for store.size > store.maxBytes {
+		store.remove(store.lru.Back().Value.(*memoryCacheItem).key)
+	}
+}
+
+// Delete removes the entry stored under key
+func (store *MemoryCacheStore) Delete(key string) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	store.remove(key)
+}
+
+// Len returns the number of stored entries
+func (store *MemoryCacheStore) Len() int {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	return store.lru.Len()
+}
+
+// Size returns the total size of the stored entries in bytes
+func (store *MemoryCacheStore) Size() int64 {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	return store.size
+}
+
+func (store *MemoryCacheStore) remove(key string) {
+	element, ok := store.items[key]
+	if !ok {
+		return
+	}
+	store.lru.Remove(element)
+	delete(store.items, key)
+	store.size -= element.Value.(*memoryCacheItem).size
+}
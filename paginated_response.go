@@ -0,0 +1,447 @@
+package response
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/gopi-frame/exception"
+)
+
+type paginationMode int
+
+const (
+	pageMode paginationMode = iota
+	offsetMode
+	cursorMode
+)
+
+// Pagination describes the position of a page in a collection, it is created by [PageBased], [OffsetBased]
+// or [CursorBased]. The total number of items is optional, without it the next page is assumed to exist
+// when the page is full and no last page is linked.
+type Pagination struct {
+	mode          paginationMode
+	position      int
+	size          int
+	nextCursor    string
+	prevCursor    string
+	total         int
+	hasTotal      bool
+	positionParam string
+	sizeParam     string
+}
+
+// PageBased returns the pagination of the page, counted from 1, with perPage items,
+// its links use the page and per_page query parameters
+func PageBased(page, perPage int) *Pagination {
+	if page < 1 {
+		panic(exception.NewArgumentException("page", page, "page must be greater than 0"))
+	}
+	if perPage < 1 {
+		panic(exception.NewArgumentException("perPage", perPage, "perPage must be greater than 0"))
+	}
+	return &Pagination{mode: pageMode, position: page, size: perPage, positionParam: "page", sizeParam: "per_page"}
+}
+
+// OffsetBased returns the pagination of limit items starting at offset,
+// its links use the offset and limit query parameters
+func OffsetBased(offset, limit int) *Pagination {
+	if offset < 0 {
+		panic(exception.NewArgumentException("offset", offset, "offset must not be negative"))
+	}
+	if limit < 1 {
+		panic(exception.NewArgumentException("limit", limit, "limit must be greater than 0"))
+	}
+	return &Pagination{mode: offsetMode, position: offset, size: limit, positionParam: "offset", sizeParam: "limit"}
+}
+
+// CursorBased returns the pagination of limit items between the cursors of the previous and the next page,
+// an empty cursor means there is no such page. Its links use the cursor and limit query parameters.
+func CursorBased(limit int, next, prev string) *Pagination {
+	if limit < 1 {
+		panic(exception.NewArgumentException("limit", limit, "limit must be greater than 0"))
+	}
+	return &Pagination{mode: cursorMode, size: limit, nextCursor: next, prevCursor: prev, positionParam: "cursor", sizeParam: "limit"}
+}
+
+// SetTotal sets the total number of items in the collection
+func (pagination *Pagination) SetTotal(total int) *Pagination {
+	if total < 0 {
+		panic(exception.NewArgumentException("total", total, "total must not be negative"))
+	}
+	pagination.total = total
+	pagination.hasTotal = true
+	return pagination
+}
+
+// Total returns the total number of items and if it is known
+func (pagination *Pagination) Total() (int, bool) {
+	return pagination.total, pagination.hasTotal
+}
+
+// SetQueryParams sets the names of the query parameters of the position (page, offset or cursor) and the page size
+func (pagination *Pagination) SetQueryParams(position, size string) *Pagination {
+	pagination.positionParam = position
+	pagination.sizeParam = size
+	return pagination
+}
+
+// lastPosition returns the page or the offset of the last page, the total must be known
+func (pagination *Pagination) lastPosition() int {
+	if pagination.mode == pageMode {
+		return max(1, (pagination.total+pagination.size-1)/pagination.size)
+	}
+	if pagination.total == 0 {
+		return 0
+	}
+	return (pagination.total - 1) / pagination.size * pagination.size
+}
+
+// paginationField is a member of the pagination metadata, kept in order for XML
+type paginationField struct {
+	name  string
+	value any
+}
+
+// meta returns the pagination metadata
+func (pagination *Pagination) meta() []paginationField {
+	var fields []paginationField
+	switch pagination.mode {
+	case pageMode:
+		fields = append(fields, paginationField{"page", pagination.position}, paginationField{"per_page", pagination.size})
+		if pagination.hasTotal {
+			fields = append(fields, paginationField{"last_page", pagination.lastPosition()})
+		}
+	case offsetMode:
+		fields = append(fields, paginationField{"offset", pagination.position}, paginationField{"limit", pagination.size})
+	case cursorMode:
+		fields = append(fields, paginationField{"limit", pagination.size})
+		if pagination.nextCursor != "" {
+			fields = append(fields, paginationField{"next_cursor", pagination.nextCursor})
+		}
+		if pagination.prevCursor != "" {
+			fields = append(fields, paginationField{"prev_cursor", pagination.prevCursor})
+		}
+	}
+	if pagination.hasTotal {
+		fields = append(fields, paginationField{"total", pagination.total})
+	}
+	return fields
+}
+
+// PaginationEnvelope sets the members of the body of a [PaginatedResponse].
+//   - DataKey: The member of the items, when it is empty the items are sent without an envelope.
+//   - MetaKey: The member of the pagination metadata, when it is empty the metadata is left out.
+//   - LinksKey: The member of the page links, when it is empty the links are only sent in the Link header.
+type PaginationEnvelope struct {
+	DataKey  string
+	MetaKey  string
+	LinksKey string
+}
+
+// defaultPaginationEnvelope is the envelope of paginated responses
+var defaultPaginationEnvelope = PaginationEnvelope{DataKey: "data", MetaKey: "meta", LinksKey: "links"}
+
+// SetDefaultPaginationEnvelope sets the envelope of paginated responses which do not set their own
+func SetDefaultPaginationEnvelope(envelope PaginationEnvelope) {
+	defaultPaginationEnvelope = envelope
+}
+
+// DefaultPaginationEnvelope returns the envelope of paginated responses
+func DefaultPaginationEnvelope() PaginationEnvelope {
+	return defaultPaginationEnvelope
+}
+
+// paginationLink is a link to another page of the collection
+type paginationLink struct {
+	rel  string
+	href string
+}
+
+// PaginatedResponse is used to send a page of a collection.
+// The links to the first, previous, next and last pages are built from the URL of the request, keeping its other
+// query parameters, and are sent in an RFC 8288 Link header and in the body next to the items and the pagination
+// metadata, as set by the [PaginationEnvelope]. The X-Total-Count header is sent when enabled and the total is known.
+// The body is encoded as JSON, or as XML after AsXML. A field mask selects the fields of the items, the envelope is
+// always sent.
+type PaginatedResponse struct {
+	*JSONResponse
+	items      any
+	count      int
+	pagination *Pagination
+	envelope   *PaginationEnvelope
+	baseURL    *url.URL
+	totalCount bool
+	xml        bool
+	xmlOptions XMLOptions
+}
+
+// SetContent sets the items of the page, a slice or an array
+func (paginated *PaginatedResponse) SetContent(items any) {
+	value := reflect.ValueOf(items)
+	switch {
+	case items == nil:
+		paginated.count = 0
+	case value.Kind() == reflect.Slice || value.Kind() == reflect.Array:
+		paginated.count = value.Len()
+	default:
+		panic(exception.NewArgumentException("items", items, "items must be a slice or an array"))
+	}
+	paginated.items = items
+}
+
+// SetPagination sets the position of the page
+func (paginated *PaginatedResponse) SetPagination(pagination *Pagination) *PaginatedResponse {
+	paginated.pagination = pagination
+	return paginated
+}
+
+// SetEnvelope sets the envelope of the body, it overrides [DefaultPaginationEnvelope]
+func (paginated *PaginatedResponse) SetEnvelope(envelope PaginationEnvelope) *PaginatedResponse {
+	paginated.envelope = &envelope
+	return paginated
+}
+
+// SetBaseURL sets the scheme, host and path of the links, for services behind a proxy,
+// the query parameters still come from the request
+func (paginated *PaginatedResponse) SetBaseURL(baseURL string) *PaginatedResponse {
+	base, err := url.Parse(baseURL)
+	if err != nil || !base.IsAbs() {
+		panic(exception.NewArgumentException("baseURL", baseURL, "baseURL must be an absolute URL"))
+	}
+	paginated.baseURL = base
+	return paginated
+}
+
+// WithTotalCount sends the total number of items in the X-Total-Count header
+func (paginated *PaginatedResponse) WithTotalCount() *PaginatedResponse {
+	paginated.totalCount = true
+	return paginated
+}
+
+// AsXML encodes the body as XML, the items are wrapped in a <collection> element
+func (paginated *PaginatedResponse) AsXML(options ...XMLOptions) *PaginatedResponse {
+	paginated.xml = true
+	if len(options) > 0 {
+		paginated.xmlOptions = options[0]
+	}
+	return paginated
+}
+
+// ServeHTTP sends the page with its links
+func (paginated *PaginatedResponse) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	paginated.send(w, r, paginated.serve)
+}
+
+func (paginated *PaginatedResponse) serve(w http.ResponseWriter, r *http.Request) {
+	envelope := defaultPaginationEnvelope
+	if paginated.envelope != nil {
+		envelope = *paginated.envelope
+	}
+	links := paginated.links(r)
+	// the headers are added to a copy so the response can be served again
+	response := *paginated.Response
+	response.headers = paginated.headers.Clone()
+	if len(links) > 0 {
+		values := make([]string, 0, len(links))
+		for _, link := range links {
+			values = append(values, fmt.Sprintf("<%s>; rel=%q", link.href, link.rel))
+		}
+		response.headers.Add("Link", strings.Join(values, ", "))
+	}
+	if paginated.totalCount && paginated.pagination != nil && paginated.pagination.hasTotal {
+		response.headers.Set("X-Total-Count", strconv.Itoa(paginated.pagination.total))
+	}
+	if paginated.xml {
+		document := &paginatedDocument{envelope: envelope, items: paginated.items, links: links}
+		if paginated.pagination != nil {
+			document.meta = paginated.pagination.meta()
+		}
+		(&XMLResponse{Response: &response, data: document, options: paginated.xmlOptions}).serve(w, r)
+		return
+	}
+	items := paginated.items
+	if paginated.count == 0 {
+		items = []any{}
+	}
+	var body any = items
+	// the mask selects the fields of the items, not the members of the envelope
+	fields := paginated.fields
+	fields.member = envelope.DataKey
+	if envelope.DataKey != "" {
+		members := map[string]any{envelope.DataKey: items}
+		if envelope.MetaKey != "" && paginated.pagination != nil {
+			meta := make(map[string]any)
+			for _, field := range paginated.pagination.meta() {
+				meta[field.name] = field.value
+			}
+			members[envelope.MetaKey] = meta
+		}
+		if envelope.LinksKey != "" {
+			hrefs := make(map[string]string, len(links))
+			for _, link := range links {
+				hrefs[link.rel] = link.href
+			}
+			members[envelope.LinksKey] = hrefs
+		}
+		body = members
+	}
+	(&JSONResponse{Response: &response, data: body, options: paginated.options, fields: fields}).serve(w, r)
+}
+
+// links returns the links to the first, previous, next and last pages which exist
+func (paginated *PaginatedResponse) links(r *http.Request) []paginationLink {
+	pagination := paginated.pagination
+	if pagination == nil {
+		return nil
+	}
+	base := paginated.baseURL
+	if base == nil {
+		base = &url.URL{Scheme: "http", Host: r.Host, Path: r.URL.Path}
+		if r.TLS != nil {
+			base.Scheme = "https"
+		}
+	}
+	query := r.URL.Query()
+	href := func(position string) string {
+		link := *base
+		values := make(url.Values, len(query)+2)
+		for key, value := range query {
+			values[key] = value
+		}
+		values.Del(pagination.positionParam)
+		if position != "" {
+			values.Set(pagination.positionParam, position)
+		}
+		values.Set(pagination.sizeParam, strconv.Itoa(pagination.size))
+		link.RawQuery = values.Encode()
+		return link.String()
+	}
+	var links []paginationLink
+	add := func(rel, position string) {
+		links = append(links, paginationLink{rel: rel, href: href(position)})
+	}
+	full := paginated.count >= pagination.size
+	switch pagination.mode {
+	case pageMode:
+		page := pagination.position
+		add("first", "1")
+		if page > 1 {
+			prev := page - 1
+			if pagination.hasTotal {
+				prev = min(prev, pagination.lastPosition())
+			}
+			add("prev", strconv.Itoa(prev))
+		}
+		if pagination.hasTotal && page < pagination.lastPosition() || !pagination.hasTotal && full {
+			add("next", strconv.Itoa(page+1))
+		}
+		if pagination.hasTotal {
+			add("last", strconv.Itoa(pagination.lastPosition()))
+		}
+	case offsetMode:
+		offset := pagination.position
+		add("first", "0")
+		if offset > 0 {
+			add("prev", strconv.Itoa(max(0, offset-pagination.size)))
+		}
+		if pagination.hasTotal && offset+pagination.size < pagination.total || !pagination.hasTotal && full {
+			add("next", strconv.Itoa(offset+pagination.size))
+		}
+		if pagination.hasTotal {
+			add("last", strconv.Itoa(pagination.lastPosition()))
+		}
+	case cursorMode:
+		add("first", "")
+		if pagination.prevCursor != "" {
+			add("prev", pagination.prevCursor)
+		}
+		if pagination.nextCursor != "" {
+			add("next", pagination.nextCursor)
+		}
+	}
+	return links
+}
+
+// paginatedDocument is the XML body of a paginated response
+type paginatedDocument struct {
+	envelope PaginationEnvelope
+	items    any
+	meta     []paginationField
+	links    []paginationLink
+}
+
+// MarshalXML encodes the items in a <collection> element, with <meta> and <links> elements when the envelope has them
+func (document *paginatedDocument) MarshalXML(e *xml.Encoder, _ xml.StartElement) error {
+	start := xml.StartElement{Name: xml.Name{Local: "collection"}}
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+	if document.envelope.DataKey != "" {
+		if document.envelope.MetaKey != "" && len(document.meta) > 0 {
+			meta := xml.StartElement{Name: xml.Name{Local: document.envelope.MetaKey}}
+			if err := e.EncodeToken(meta); err != nil {
+				return err
+			}
+			for _, field := range document.meta {
+				if err := e.EncodeElement(field.value, xml.StartElement{Name: xml.Name{Local: field.name}}); err != nil {
+					return err
+				}
+			}
+			if err := e.EncodeToken(meta.End()); err != nil {
+				return err
+			}
+		}
+		if document.envelope.LinksKey != "" {
+			links := xml.StartElement{Name: xml.Name{Local: document.envelope.LinksKey}}
+			if err := e.EncodeToken(links); err != nil {
+				return err
+			}
+			for _, link := range document.links {
+				element := xml.StartElement{Name: xml.Name{Local: "link"}, Attr: []xml.Attr{
+					{Name: xml.Name{Local: "rel"}, Value: link.rel},
+					{Name: xml.Name{Local: "href"}, Value: link.href},
+				}}
+				if err := e.EncodeToken(element); err != nil {
+					return err
+				}
+				if err := e.EncodeToken(element.End()); err != nil {
+					return err
+				}
+			}
+			if err := e.EncodeToken(links.End()); err != nil {
+				return err
+			}
+		}
+		data := xml.StartElement{Name: xml.Name{Local: document.envelope.DataKey}}
+		if err := e.EncodeToken(data); err != nil {
+			return err
+		}
+		if err := document.encodeItems(e); err != nil {
+			return err
+		}
+		if err := e.EncodeToken(data.End()); err != nil {
+			return err
+		}
+	} else if err := document.encodeItems(e); err != nil {
+		return err
+	}
+	return e.EncodeToken(start.End())
+}
+
+func (document *paginatedDocument) encodeItems(e *xml.Encoder) error {
+	if document.items == nil {
+		return nil
+	}
+	items := reflect.ValueOf(document.items)
+	for i := 0; i < items.Len(); i++ {
+		if err := e.Encode(items.Index(i).Interface()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
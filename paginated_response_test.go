@@ -0,0 +1,151 @@
+package response
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type paginatedUser struct {
+	ID   int    `json:"id" xml:"id"`
+	Name string `json:"name" xml:"name"`
+}
+
+func TestPaginatedResponse_PageBased(t *testing.T) {
+	items := []paginatedUser{{ID: 3, Name: "Carol"}, {ID: 4, Name: "Dave"}}
+	response := New(200).Paginate(items, PageBased(2, 2).SetTotal(5)).WithTotalCount()
+	recorder := httptest.NewRecorder()
+	response.ServeHTTP(recorder, httptest.NewRequest("GET", "/users?status=active&page=2", nil))
+	assert.Equal(t, 200, recorder.Code)
+	assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+	assert.Equal(t, "5", recorder.Header().Get("X-Total-Count"))
+	assert.Equal(t, `<http://example.com/users?page=1&per_page=2&status=active>; rel="first", `+
+		`<http://example.com/users?page=1&per_page=2&status=active>; rel="prev", `+
+		`<http://example.com/users?page=3&per_page=2&status=active>; rel="next", `+
+		`<http://example.com/users?page=3&per_page=2&status=active>; rel="last"`, recorder.Header().Get("Link"))
+	assert.JSONEq(t, `{
+		"data": [{"id":3,"name":"Carol"},{"id":4,"name":"Dave"}],
+		"meta": {"page":2,"per_page":2,"last_page":3,"total":5},
+		"links": {
+			"first": "http://example.com/users?page=1&per_page=2&status=active",
+			"prev": "http://example.com/users?page=1&per_page=2&status=active",
+			"next": "http://example.com/users?page=3&per_page=2&status=active",
+			"last": "http://example.com/users?page=3&per_page=2&status=active"
+		}
+	}`, recorder.Body.String())
+
+	// serving again does not repeat the links
+	recorder = httptest.NewRecorder()
+	response.ServeHTTP(recorder, httptest.NewRequest("GET", "/users", nil))
+	assert.Len(t, recorder.Header().Values("Link"), 1)
+	assert.Empty(t, response.Header("Link"))
+}
+
+func TestPaginatedResponse_OffsetBased(t *testing.T) {
+	cases := []struct {
+		name       string
+		pagination *Pagination
+		count      int
+		links      map[string]string
+	}{
+		{"first page", OffsetBased(0, 10).SetTotal(25), 10, map[string]string{
+			"first": "?limit=10&offset=0", "next": "?limit=10&offset=10", "last": "?limit=10&offset=20",
+		}},
+		{"last page", OffsetBased(20, 10).SetTotal(25), 5, map[string]string{
+			"first": "?limit=10&offset=0", "prev": "?limit=10&offset=10", "last": "?limit=10&offset=20",
+		}},
+		{"unknown total full", OffsetBased(5, 10), 10, map[string]string{
+			"first": "?limit=10&offset=0", "prev": "?limit=10&offset=0", "next": "?limit=10&offset=15",
+		}},
+		{"unknown total partial", OffsetBased(0, 10), 3, map[string]string{
+			"first": "?limit=10&offset=0",
+		}},
+		{"empty", OffsetBased(0, 10).SetTotal(0), 0, map[string]string{
+			"first": "?limit=10&offset=0", "last": "?limit=10&offset=0",
+		}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			response := New(200).Paginate(make([]int, c.count), c.pagination).SetBaseURL("https://api.example.com/v1/items")
+			links := make(map[string]string)
+			for _, link := range response.links(httptest.NewRequest("GET", "/items", nil)) {
+				links[link.rel] = link.href[len("https://api.example.com/v1/items"):]
+			}
+			assert.Equal(t, c.links, links)
+		})
+	}
+}
+
+func TestPaginatedResponse_CursorBased(t *testing.T) {
+	response := New(200).Paginate([]string{"a", "b"}, CursorBased(2, "b", "a").SetQueryParams("after", "size")).
+		SetEnvelope(PaginationEnvelope{DataKey: "items", MetaKey: "page"})
+	request := httptest.NewRequest("GET", "https://example.com/letters?after=x", nil)
+	recorder := httptest.NewRecorder()
+	response.ServeHTTP(recorder, request)
+	assert.Equal(t, `<https://example.com/letters?size=2>; rel="first", `+
+		`<https://example.com/letters?after=a&size=2>; rel="prev", `+
+		`<https://example.com/letters?after=b&size=2>; rel="next"`, recorder.Header().Get("Link"))
+	assert.Empty(t, recorder.Header().Get("X-Total-Count"))
+	assert.JSONEq(t, `{"items":["a","b"],"page":{"limit":2,"next_cursor":"b","prev_cursor":"a"}}`, recorder.Body.String())
+}
+
+func TestPaginatedResponse_Bare(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	New(200).Paginate(nil, PageBased(1, 10)).SetEnvelope(PaginationEnvelope{}).
+		ServeHTTP(recorder, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, "[]", recorder.Body.String())
+	assert.Equal(t, `<http://example.com/?page=1&per_page=10>; rel="first"`, recorder.Header().Get("Link"))
+}
+
+func TestPaginatedResponse_FieldMask(t *testing.T) {
+	items := []paginatedUser{{ID: 3, Name: "Carol"}, {ID: 4, Name: "Dave"}}
+	response := New(200).Paginate(items, PageBased(2, 2).SetTotal(5))
+	response.FieldsFromQuery().SetStrictFields(true)
+	recorder := httptest.NewRecorder()
+	response.ServeHTTP(recorder, httptest.NewRequest("GET", "/users?page=2&fields=id", nil))
+	assert.Equal(t, 200, recorder.Code)
+	assert.JSONEq(t, `{
+		"data": [{"id":3},{"id":4}],
+		"meta": {"page":2,"per_page":2,"last_page":3,"total":5},
+		"links": {
+			"first": "http://example.com/users?fields=id&page=1&per_page=2",
+			"prev": "http://example.com/users?fields=id&page=1&per_page=2",
+			"next": "http://example.com/users?fields=id&page=3&per_page=2",
+			"last": "http://example.com/users?fields=id&page=3&per_page=2"
+		}
+	}`, recorder.Body.String())
+
+	// unknown paths are checked against the items
+	recorder = httptest.NewRecorder()
+	response.ServeHTTP(recorder, httptest.NewRequest("GET", "/users?fields=id,meta", nil))
+	assert.Equal(t, 400, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), `"fields":["meta"]`)
+
+	recorder = httptest.NewRecorder()
+	response.SetEnvelope(PaginationEnvelope{}).ServeHTTP(recorder, httptest.NewRequest("GET", "/users?fields=name", nil))
+	assert.JSONEq(t, `[{"name":"Carol"},{"name":"Dave"}]`, recorder.Body.String())
+}
+
+func TestPaginatedResponse_XML(t *testing.T) {
+	items := []paginatedUser{{ID: 1, Name: "Alice"}}
+	recorder := httptest.NewRecorder()
+	New(200).Paginate(items, PageBased(1, 1).SetTotal(1)).AsXML().
+		ServeHTTP(recorder, httptest.NewRequest("GET", "/users", nil))
+	assert.Equal(t, "application/xml", recorder.Header().Get("Content-Type"))
+	assert.Equal(t, `<collection>`+
+		`<meta><page>1</page><per_page>1</per_page><last_page>1</last_page><total>1</total></meta>`+
+		`<links><link rel="first" href="http://example.com/users?page=1&amp;per_page=1"></link>`+
+		`<link rel="last" href="http://example.com/users?page=1&amp;per_page=1"></link></links>`+
+		`<data><paginatedUser><id>1</id><name>Alice</name></paginatedUser></data>`+
+		`</collection>`, recorder.Body.String())
+}
+
+func TestPaginatedResponse_InvalidArguments(t *testing.T) {
+	assert.Panics(t, func() { PageBased(0, 10) })
+	assert.Panics(t, func() { OffsetBased(-1, 10) })
+	assert.Panics(t, func() { CursorBased(0, "", "") })
+	assert.Panics(t, func() { PageBased(1, 10).SetTotal(-1) })
+	assert.Panics(t, func() { New(200).Paginate("items", PageBased(1, 10)) })
+	assert.Panics(t, func() { New(200).Paginate(nil, PageBased(1, 10)).SetBaseURL("/relative") })
+}
@@ -0,0 +1,202 @@
+package response
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+
+	"github.com/gopi-frame/exception"
+)
+
+// ProxyResponse is used to reply with the response of an upstream service through [httputil.ReverseProxy].
+// The upstream status code and body are sent as is, while the headers and cookies set on the embedded [Response],
+// and its cookie, security, CORS and cache policies, are merged into the upstream headers:
+// Vary names are merged, security headers sent by the upstream are kept, and the upstream cookies pass through
+// the cookie policy, a cookie which violates it is handled by the error handler.
+//
+// Absolute Location headers pointing to the upstream host are rewritten to the host of the incoming request,
+// and the domains of upstream cookies can be rewritten with RewriteCookieDomain.
+// When the upstream can not be reached, the response of the error handler is sent instead of the default 502 text,
+// see SetErrorHandler.
+type ProxyResponse struct {
+	*Response
+	target        *url.URL
+	transport     http.RoundTripper
+	rewrites      []func(*httputil.ProxyRequest)
+	modifiers     []func(*http.Response) error
+	cookieDomains map[string]string
+	keepLocation  bool
+	errorHandler  func(r *http.Request, err error) http.Handler
+}
+
+// SetTarget sets the upstream URL, the incoming request path is appended to its path
+func (proxyResponse *ProxyResponse) SetTarget(target string) *ProxyResponse {
+	u, err := url.Parse(target)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		panic(exception.NewArgumentException("target", target, "Invalid proxy target: "+target))
+	}
+	proxyResponse.target = u
+	return proxyResponse
+}
+
+// SetTransport sets the transport used to reach the upstream, [http.DefaultTransport] is used when it is nil
+func (proxyResponse *ProxyResponse) SetTransport(transport http.RoundTripper) *ProxyResponse {
+	proxyResponse.transport = transport
+	return proxyResponse
+}
+
+// Rewrite adds a hook which edits the outgoing request, it runs after the target URL and the X-Forwarded headers are set
+func (proxyResponse *ProxyResponse) Rewrite(rewrite func(*httputil.ProxyRequest)) *ProxyResponse {
+	proxyResponse.rewrites = append(proxyResponse.rewrites, rewrite)
+	return proxyResponse
+}
+
+// ModifyResponse adds a hook which edits the upstream response, an error is handled by the error handler
+func (proxyResponse *ProxyResponse) ModifyResponse(modifier func(*http.Response) error) *ProxyResponse {
+	proxyResponse.modifiers = append(proxyResponse.modifiers, modifier)
+	return proxyResponse
+}
+
+// RewriteCookieDomain replaces the domain of upstream cookies, an empty domain removes the attribute
+func (proxyResponse *ProxyResponse) RewriteCookieDomain(upstream, domain string) *ProxyResponse {
+	if proxyResponse.cookieDomains == nil {
+		proxyResponse.cookieDomains = make(map[string]string)
+	}
+	proxyResponse.cookieDomains[strings.ToLower(strings.TrimPrefix(upstream, "."))] = domain
+	return proxyResponse
+}
+
+// KeepLocation disables the rewriting of Location headers pointing to the upstream host
+func (proxyResponse *ProxyResponse) KeepLocation() *ProxyResponse {
+	proxyResponse.keepLocation = true
+	return proxyResponse
+}
+
+// SetErrorHandler sets the function which returns the response sent when the upstream fails
+func (proxyResponse *ProxyResponse) SetErrorHandler(handler func(r *http.Request, err error) http.Handler) *ProxyResponse {
+	proxyResponse.errorHandler = handler
+	return proxyResponse
+}
+
+// ServeHTTP proxies the request to the upstream and sends its response
+func (proxyResponse *ProxyResponse) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	proxyResponse.send(w, r, proxyResponse.serve)
+}
+
+func (proxyResponse *ProxyResponse) serve(w http.ResponseWriter, r *http.Request) {
+	if proxyResponse.target == nil {
+		panic(exception.New("proxy target is not set"))
+	}
+	proxy := &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			pr.SetURL(proxyResponse.target)
+			pr.SetXForwarded()
+			for _, rewrite := range proxyResponse.rewrites {
+				rewrite(pr)
+			}
+		},
+		Transport: proxyResponse.transport,
+		ModifyResponse: func(resp *http.Response) error {
+			for _, modifier := range proxyResponse.modifiers {
+				if err := modifier(resp); err != nil {
+					return err
+				}
+			}
+			proxyResponse.rewriteLocation(resp.Header, r)
+			proxyResponse.rewriteCookies(resp.Header)
+			return proxyResponse.mergeHeaders(resp.Header, r)
+		},
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			proxyResponse.handleError(w, r, err)
+		},
+	}
+	proxy.ServeHTTP(w, r)
+}
+
+// mergeHeaders merges the headers the embedded response would send into the upstream headers.
+// The Vary names are merged, the security headers sent by the upstream are kept,
+// and the upstream cookies are passed through the cookie policy together with the cookies of the response.
+func (proxyResponse *ProxyResponse) mergeHeaders(header http.Header, r *http.Request) error {
+	writer := newBufferedResponseWriter()
+	proxyResponse.sendHeaders(writer, r)
+	for key, values := range writer.header {
+		switch {
+		case key == "Set-Cookie":
+		case key == "Vary":
+			appendVary(header, varyNames(writer.header)...)
+		case securityHeaderNames[key] && len(header[key]) > 0:
+		default:
+			header[key] = values
+		}
+	}
+	policy := proxyResponse.CookiePolicy()
+	if policy == nil && len(proxyResponse.cookies) == 0 {
+		return nil
+	}
+	cookies := append((&http.Response{Header: header}).Cookies(), proxyResponse.cookies...)
+	if policy != nil {
+		var err error
+		if cookies, err = policy.Apply(cookies); err != nil {
+			return err
+		}
+	} else {
+		cookies = dedupeCookies(cookies)
+	}
+	header.Del("Set-Cookie")
+	for _, cookie := range cookies {
+		header.Add("Set-Cookie", cookie.String())
+	}
+	return nil
+}
+
+func (proxyResponse *ProxyResponse) rewriteLocation(header http.Header, r *http.Request) {
+	location := header.Get("Location")
+	if proxyResponse.keepLocation || location == "" {
+		return
+	}
+	u, err := url.Parse(location)
+	if err != nil || !strings.EqualFold(u.Host, proxyResponse.target.Host) {
+		return
+	}
+	u.Host = r.Host
+	u.Scheme = "http"
+	if r.TLS != nil {
+		u.Scheme = "https"
+	}
+	header.Set("Location", u.String())
+}
+
+func (proxyResponse *ProxyResponse) rewriteCookies(header http.Header) {
+	if len(proxyResponse.cookieDomains) == 0 || len(header.Values("Set-Cookie")) == 0 {
+		return
+	}
+	cookies := (&http.Response{Header: header}).Cookies()
+	header.Del("Set-Cookie")
+	for _, cookie := range cookies {
+		if domain, ok := proxyResponse.cookieDomains[strings.ToLower(strings.TrimPrefix(cookie.Domain, "."))]; ok && cookie.Domain != "" {
+			cookie.Domain = domain
+		}
+		header.Add("Set-Cookie", cookie.String())
+	}
+}
+
+func (proxyResponse *ProxyResponse) handleError(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, context.Canceled) && r.Context().Err() != nil {
+		// the client is gone, there is nobody to reply to
+		return
+	}
+	if proxyResponse.errorHandler != nil {
+		proxyResponse.errorHandler(r, err).ServeHTTP(w, r)
+		return
+	}
+	statusCode := http.StatusBadGateway
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		statusCode = http.StatusGatewayTimeout
+	}
+	New(statusCode, http.StatusText(statusCode)).ServeHTTP(w, r)
+}
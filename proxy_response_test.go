@@ -0,0 +1,167 @@
+package response
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResponse_Proxy(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Path", r.URL.Path)
+		w.Header().Set("X-Forwarded-Host", r.Header.Get("X-Forwarded-Host"))
+		w.Header().Set("X-Tenant", r.Header.Get("X-Tenant"))
+		w.Header().Set("X-Powered-By", "upstream")
+		w.Header().Set("Location", "http://"+r.Host+"/api/items/1")
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "abc", Domain: "internal.local", Path: "/"})
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte("created"))
+	}))
+	defer upstream.Close()
+
+	response := New(http.StatusOK).Proxy(upstream.URL+"/api").
+		Rewrite(func(pr *httputil.ProxyRequest) {
+			pr.Out.Header.Set("X-Tenant", "acme")
+		}).
+		RewriteCookieDomain("internal.local", "example.com")
+	response.SetHeader("X-Powered-By", "gateway")
+	response.SetCookie(&http.Cookie{Name: "gateway", Value: "1"})
+
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest("POST", "http://example.com/items", nil)
+	response.ServeHTTP(recorder, request)
+
+	result := recorder.Result()
+	body, _ := io.ReadAll(result.Body)
+	assert.Equal(t, http.StatusCreated, result.StatusCode)
+	assert.Equal(t, "created", string(body))
+	assert.Equal(t, "/api/items", result.Header.Get("X-Path"))
+	assert.Equal(t, "example.com", result.Header.Get("X-Forwarded-Host"))
+	assert.Equal(t, "acme", result.Header.Get("X-Tenant"))
+	assert.Equal(t, "gateway", result.Header.Get("X-Powered-By"))
+	assert.Equal(t, "http://example.com/api/items/1", result.Header.Get("Location"))
+	cookies := result.Cookies()
+	if assert.Len(t, cookies, 2) {
+		assert.Equal(t, "session", cookies[0].Name)
+		assert.Equal(t, "example.com", cookies[0].Domain)
+		assert.Equal(t, "gateway", cookies[1].Name)
+	}
+}
+
+func TestProxyResponse_MergeHeaders(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Vary", "Accept-Encoding")
+		w.Header().Set("Content-Security-Policy", "default-src 'none'")
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "abc", Path: "/"})
+		http.SetCookie(w, &http.Cookie{Name: "theme", Value: "dark", Path: "/"})
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer upstream.Close()
+
+	response := New(http.StatusOK).Proxy(upstream.URL)
+	response.SetCORSPolicy(&CORSPolicy{AllowedOrigins: []string{"https://app.example.com", "https://admin.example.com"}})
+	response.SetSecurityHeaders(StrictSecurityHeaders())
+	response.SetCookiePolicy(&CookiePolicy{HttpOnly: true})
+	response.ForgetCookie("theme").Path = "/"
+
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest("GET", "http://example.com/", nil)
+	request.Header.Set("Origin", "https://app.example.com")
+	response.ServeHTTP(recorder, request)
+
+	result := recorder.Result()
+	assert.Equal(t, http.StatusOK, result.StatusCode)
+	assert.Equal(t, []string{"Accept-Encoding", "Origin"}, result.Header.Values("Vary"))
+	assert.Equal(t, "default-src 'none'", result.Header.Get("Content-Security-Policy"))
+	assert.Equal(t, "nosniff", result.Header.Get("X-Content-Type-Options"))
+	cookies := result.Cookies()
+	if assert.Len(t, cookies, 2) {
+		assert.Equal(t, "session", cookies[0].Name)
+		assert.True(t, cookies[0].HttpOnly)
+		assert.Equal(t, "theme", cookies[1].Name)
+		assert.Equal(t, -1, cookies[1].MaxAge)
+	}
+
+	rejected := New(http.StatusOK).Proxy(upstream.URL)
+	rejected.SetCookiePolicy(&CookiePolicy{MaxSize: 10})
+	recorder = httptest.NewRecorder()
+	rejected.ServeHTTP(recorder, httptest.NewRequest("GET", "http://example.com/", nil))
+	assert.Equal(t, http.StatusBadGateway, recorder.Code)
+}
+
+func TestProxyResponse_KeepLocation(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "http://"+r.Host+"/login", http.StatusFound)
+	}))
+	defer upstream.Close()
+
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest("GET", "http://example.com/", nil)
+	New(http.StatusOK).Proxy(upstream.URL).KeepLocation().ServeHTTP(recorder, request)
+	assert.Equal(t, upstream.URL+"/login", recorder.Header().Get("Location"))
+}
+
+func TestProxyResponse_ModifyResponse(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer upstream.Close()
+
+	t.Run("edit", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		New(http.StatusOK).Proxy(upstream.URL).
+			ModifyResponse(func(resp *http.Response) error {
+				resp.Header.Set("X-Modified", "1")
+				return nil
+			}).
+			ServeHTTP(recorder, httptest.NewRequest("GET", "/", nil))
+		assert.Equal(t, "1", recorder.Header().Get("X-Modified"))
+		assert.Equal(t, "ok", recorder.Body.String())
+	})
+
+	t.Run("error", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		New(http.StatusOK).Proxy(upstream.URL).
+			ModifyResponse(func(resp *http.Response) error {
+				return errors.New("rejected")
+			}).
+			ServeHTTP(recorder, httptest.NewRequest("GET", "/", nil))
+		assert.Equal(t, http.StatusBadGateway, recorder.Code)
+		assert.Equal(t, "Bad Gateway", recorder.Body.String())
+	})
+}
+
+func TestProxyResponse_ErrorHandler(t *testing.T) {
+	upstream := httptest.NewServer(http.NotFoundHandler())
+	target := upstream.URL
+	upstream.Close()
+
+	t.Run("default", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		New(http.StatusOK).Proxy(target).ServeHTTP(recorder, httptest.NewRequest("GET", "/", nil))
+		assert.Equal(t, http.StatusBadGateway, recorder.Code)
+		assert.Equal(t, "Bad Gateway", recorder.Body.String())
+	})
+
+	t.Run("custom", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		New(http.StatusOK).Proxy(target).
+			SetErrorHandler(func(r *http.Request, err error) http.Handler {
+				return New(http.StatusServiceUnavailable).JSON(map[string]string{"error": "upstream unavailable"})
+			}).
+			ServeHTTP(recorder, httptest.NewRequest("GET", "/", nil))
+		assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+		assert.JSONEq(t, `{"error":"upstream unavailable"}`, recorder.Body.String())
+	})
+}
+
+func TestProxyResponse_SetTarget(t *testing.T) {
+	assert.Panics(t, func() {
+		New(http.StatusOK).Proxy("/relative")
+	})
+}
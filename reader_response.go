@@ -1,6 +1,7 @@
 package response
 
 import (
+	"bytes"
 	"io"
 	"net/http"
 
@@ -15,6 +16,7 @@ type ReaderResponse struct {
 	*Response
 	contentType string
 	reader      io.Reader
+	closer      io.Closer
 }
 
 // SetReader sets the reader
@@ -31,33 +33,44 @@ func (readerResponse *ReaderResponse) SetContentType(contentType string) *Reader
 
 // ServeHTTP sends the response
 func (readerResponse *ReaderResponse) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	// set cookies
-	for _, cookie := range readerResponse.cookies {
-		http.SetCookie(w, cookie)
-	}
-	// set headers
-	for key, value := range readerResponse.headers {
-		w.Header()[key] = value
+	readerResponse.send(w, r, readerResponse.serve)
+}
+
+func (readerResponse *ReaderResponse) serve(w http.ResponseWriter, r *http.Request) {
+	if readerResponse.closer != nil {
+		defer func() {
+			if err := readerResponse.closer.Close(); err != nil {
+				panic(err)
+			}
+		}()
 	}
+	readerResponse.sendHeaders(w, r)
+	reader := readerResponse.reader
 	// set content type
 	if readerResponse.contentType != "" {
 		w.Header().Set("content-type", readerResponse.contentType)
-	} else if readerResponse.reader != nil {
-		mime, _ := mimetype.DetectReader(readerResponse.reader)
+	} else if seeker, ok := reader.(io.ReadSeeker); ok {
+		mime, _ := mimetype.DetectReader(seeker)
 		w.Header().Set("content-type", mime.String())
 		// rewind reader
-		if _, err := readerResponse.reader.(io.ReadSeeker).Seek(0, 0); err != nil {
+		if _, err := seeker.Seek(0, 0); err != nil {
 			panic(err)
 		}
+	} else if reader != nil {
+		// keep the bytes read by the detection of a reader which can not rewind
+		sniffed := new(bytes.Buffer)
+		mime, _ := mimetype.DetectReader(io.TeeReader(reader, sniffed))
+		w.Header().Set("content-type", mime.String())
+		reader = io.MultiReader(sniffed, reader)
 	} else {
 		w.Header().Set("content-type", "application/octet-stream")
 	}
 	// set http status code
 	w.WriteHeader(readerResponse.statusCode)
-	if readerResponse.reader == nil {
+	if reader == nil || !bodyAllowedForStatus(readerResponse.statusCode) {
 		return
 	}
-	if _, err := io.Copy(w, readerResponse.reader); err != nil {
+	if _, err := io.Copy(w, reader); err != nil {
 		panic(err)
 	}
 }
@@ -25,8 +25,13 @@ func (redirectResponse *RedirectResponse) SetLocation(location string) *Redirect
 
 // ServeHTTP sends the response
 func (redirectResponse *RedirectResponse) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	redirectResponse.send(w, r, redirectResponse.serve)
+}
+
+func (redirectResponse *RedirectResponse) serve(w http.ResponseWriter, r *http.Request) {
 	if redirectResponse.statusCode < http.StatusMultipleChoices || redirectResponse.statusCode > http.StatusPermanentRedirect {
 		panic(exception.New(fmt.Sprintf("can not redirect with HTTP status code `%d`", redirectResponse.statusCode)))
 	}
+	redirectResponse.sendHeaders(w, r)
 	http.Redirect(w, r, redirectResponse.location, redirectResponse.statusCode)
 }
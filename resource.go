@@ -0,0 +1,334 @@
+package response
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"reflect"
+)
+
+// Fields is the output of a resource, fields whose value is left out by [When], [WhenFunc] or [WhenLoaded]
+// are not sent. Values can be nested resources, collections, [Fields], maps and lists, which are resolved in turn.
+type Fields map[string]any
+
+// missingValue marks a field which is left out
+type missingValue struct{}
+
+// When returns the value when the condition is true, otherwise the first of otherwise or a value which leaves
+// the field out
+func When(condition bool, value any, otherwise ...any) any {
+	if condition {
+		return value
+	}
+	if len(otherwise) > 0 {
+		return otherwise[0]
+	}
+	return missingValue{}
+}
+
+// WhenFunc returns the result of value when the condition is true, otherwise a value which leaves the field out,
+// value is not called when the condition is false
+func WhenFunc(condition bool, value func() any) any {
+	if condition {
+		return value()
+	}
+	return missingValue{}
+}
+
+// WhenLoaded returns the value of a relation, or a value which leaves the field out when the relation is nil,
+// an empty but not nil slice or map is a loaded relation without items
+func WhenLoaded(value any) any {
+	if value == nil {
+		return missingValue{}
+	}
+	switch reflected := reflect.ValueOf(value); reflected.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Map, reflect.Interface, reflect.Func, reflect.Chan:
+		if reflected.IsNil() {
+			return missingValue{}
+		}
+	}
+	return value
+}
+
+// defaultResourceWrap is the key the data of resources is wrapped under
+var defaultResourceWrap = "data"
+
+// SetDefaultResourceWrap sets the key the data of resources is wrapped under, an empty key sends the data as it is
+func SetDefaultResourceWrap(key string) {
+	defaultResourceWrap = key
+}
+
+// DefaultResourceWrap returns the key the data of resources is wrapped under
+func DefaultResourceWrap() string {
+	return defaultResourceWrap
+}
+
+// Resource transforms values of a domain type into the [Fields] sent to clients,
+// so internal structs are never encoded directly
+type Resource[T any] struct {
+	transform func(r *http.Request, value T) Fields
+}
+
+// NewResource creates a [Resource] with the transform function
+func NewResource[T any](transform func(r *http.Request, value T) Fields) *Resource[T] {
+	return &Resource[T]{transform: transform}
+}
+
+// Make returns a 200 [ResourceResponse] of the value, it can also be the value of a field of another resource
+func (resource *Resource[T]) Make(value T) *ResourceResponse {
+	return &ResourceResponse{
+		JSONResponse: New(http.StatusOK).JSON(),
+		resolve: func(r *http.Request) Fields {
+			return resource.transform(r, value)
+		},
+	}
+}
+
+// Collection returns a 200 [ResourceCollectionResponse] of the values
+func (resource *Resource[T]) Collection(values []T) *ResourceCollectionResponse {
+	return resource.CollectionFunc(func(yield func(T) bool) {
+		for _, value := range values {
+			if !yield(value) {
+				return
+			}
+		}
+	})
+}
+
+// CollectionFunc returns a 200 [ResourceCollectionResponse] of the values yielded by seq,
+// which is called each time the collection is sent
+func (resource *Resource[T]) CollectionFunc(seq func(yield func(T) bool)) *ResourceCollectionResponse {
+	return &ResourceCollectionResponse{
+		JSONResponse: New(http.StatusOK).JSON(),
+		each: func(r *http.Request, yield func(Fields) bool) {
+			seq(func(value T) bool {
+				return yield(resource.transform(r, value))
+			})
+		},
+	}
+}
+
+// resourceValue is a resource nested in the fields of another one
+type resourceValue interface {
+	resolveResource(r *http.Request) any
+}
+
+// ResourceResponse is used to send a value transformed by a [Resource].
+// The fields are wrapped under the key set by SetWrap or [SetDefaultResourceWrap], next to the top-level
+// meta and links, which are only sent when the fields are wrapped. A field mask selects the fields of the value,
+// the meta and links are always sent.
+type ResourceResponse struct {
+	*JSONResponse
+	resolve func(r *http.Request) Fields
+	wrap    *string
+	meta    map[string]any
+	links   map[string]string
+}
+
+// SetWrap sets the key the fields are wrapped under, an empty key sends the fields as they are
+func (resourceResponse *ResourceResponse) SetWrap(key string) *ResourceResponse {
+	resourceResponse.wrap = &key
+	return resourceResponse
+}
+
+// WithMeta adds members to the top-level meta
+func (resourceResponse *ResourceResponse) WithMeta(meta map[string]any) *ResourceResponse {
+	resourceResponse.meta = mergeResourceMembers(resourceResponse.meta, meta)
+	return resourceResponse
+}
+
+// WithLinks adds members to the top-level links
+func (resourceResponse *ResourceResponse) WithLinks(links map[string]string) *ResourceResponse {
+	resourceResponse.links = mergeResourceMembers(resourceResponse.links, links)
+	return resourceResponse
+}
+
+// ServeHTTP sends the transformed value
+func (resourceResponse *ResourceResponse) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	resourceResponse.send(w, r, resourceResponse.serve)
+}
+
+func (resourceResponse *ResourceResponse) serve(w http.ResponseWriter, r *http.Request) {
+	var data any = resourceResponse.resolveResource(r)
+	// the mask selects the fields of the resource, not the members of the document it is wrapped in
+	fields := resourceResponse.fields
+	fields.member = resourceWrap(resourceResponse.wrap)
+	if key := fields.member; key != "" {
+		document := map[string]any{key: data}
+		if len(resourceResponse.meta) > 0 {
+			document["meta"] = resolveResourceValue(r, resourceResponse.meta)
+		}
+		if len(resourceResponse.links) > 0 {
+			document["links"] = resourceResponse.links
+		}
+		data = document
+	}
+	(&JSONResponse{Response: resourceResponse.Response, data: data, options: resourceResponse.options, fields: fields}).serve(w, r)
+}
+
+func (resourceResponse *ResourceResponse) resolveResource(r *http.Request) any {
+	fields := resourceResponse.resolve(r)
+	if fields == nil {
+		return nil
+	}
+	return resolveResourceValue(r, fields)
+}
+
+// ResourceCollectionResponse is used to send values transformed by a [Resource].
+// The items are encoded one at a time while the values are iterated, so large collections are never copied.
+// Like a [ResourceResponse], the list is wrapped under a key next to the top-level meta and links, and the JSON options
+// and field mask apply to it, the mask selecting the fields of each item.
+type ResourceCollectionResponse struct {
+	*JSONResponse
+	each  func(r *http.Request, yield func(Fields) bool)
+	wrap  *string
+	meta  map[string]any
+	links map[string]string
+}
+
+// SetWrap sets the key the list is wrapped under, an empty key sends the list as it is
+func (collection *ResourceCollectionResponse) SetWrap(key string) *ResourceCollectionResponse {
+	collection.wrap = &key
+	return collection
+}
+
+// WithMeta adds members to the top-level meta
+func (collection *ResourceCollectionResponse) WithMeta(meta map[string]any) *ResourceCollectionResponse {
+	collection.meta = mergeResourceMembers(collection.meta, meta)
+	return collection
+}
+
+// WithLinks adds members to the top-level links
+func (collection *ResourceCollectionResponse) WithLinks(links map[string]string) *ResourceCollectionResponse {
+	collection.links = mergeResourceMembers(collection.links, links)
+	return collection
+}
+
+// ServeHTTP sends the transformed values
+func (collection *ResourceCollectionResponse) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	collection.send(w, r, collection.serve)
+}
+
+func (collection *ResourceCollectionResponse) serve(w http.ResponseWriter, r *http.Request) {
+	tree, problem := collection.fields.tree(r)
+	if problem != nil {
+		collection.serveProblem(w, r, problem)
+		return
+	}
+	buf := bytes.NewBufferString("[")
+	encoder := json.NewEncoder(buf)
+	encoder.SetEscapeHTML(!collection.options.DisableHTMLEscape)
+	var err error
+	collection.each(r, func(fields Fields) bool {
+		if r.Context().Err() != nil {
+			return false
+		}
+		var item any
+		if fields != nil {
+			item = resolveResourceValue(r, fields)
+		}
+		if item, problem = collection.fields.projectTree(item, tree); problem != nil {
+			return false
+		}
+		if buf.Len() > 1 {
+			buf.WriteByte(',')
+		}
+		err = encoder.Encode(item)
+		return err == nil
+	})
+	if err != nil {
+		panic(err)
+	}
+	if problem != nil {
+		collection.serveProblem(w, r, problem)
+		return
+	}
+	// the client is gone
+	if r.Context().Err() != nil {
+		return
+	}
+	buf.WriteByte(']')
+	var data any = json.RawMessage(buf.Bytes())
+	if key := resourceWrap(collection.wrap); key != "" {
+		document := map[string]any{key: data}
+		if len(collection.meta) > 0 {
+			document["meta"] = resolveResourceValue(r, collection.meta)
+		}
+		if len(collection.links) > 0 {
+			document["links"] = collection.links
+		}
+		data = document
+	}
+	(&JSONResponse{Response: collection.Response, data: data, options: collection.options}).serve(w, r)
+}
+
+func (collection *ResourceCollectionResponse) resolveResource(r *http.Request) any {
+	items := make([]any, 0)
+	collection.each(r, func(fields Fields) bool {
+		if fields == nil {
+			items = append(items, nil)
+		} else {
+			items = append(items, resolveResourceValue(r, fields))
+		}
+		return true
+	})
+	return items
+}
+
+// resolveResourceValue resolves nested resources and leaves out the missing fields and list items
+func resolveResourceValue(r *http.Request, value any) any {
+	switch v := value.(type) {
+	case resourceValue:
+		if reflected := reflect.ValueOf(v); reflected.Kind() == reflect.Pointer && reflected.IsNil() {
+			return nil
+		}
+		return v.resolveResource(r)
+	case Fields:
+		return resolveResourceFields(r, v)
+	case map[string]any:
+		return resolveResourceFields(r, v)
+	}
+	// lists of any type are walked, byte slices are left to encoding/json which sends them as base64
+	items := reflect.ValueOf(value)
+	switch {
+	case items.Kind() == reflect.Slice && (items.IsNil() || items.Type().Elem().Kind() == reflect.Uint8):
+		return value
+	case items.Kind() != reflect.Slice && items.Kind() != reflect.Array:
+		return value
+	}
+	resolved := make([]any, 0, items.Len())
+	for i := 0; i < items.Len(); i++ {
+		item := items.Index(i).Interface()
+		if _, missing := item.(missingValue); !missing {
+			resolved = append(resolved, resolveResourceValue(r, item))
+		}
+	}
+	return resolved
+}
+
+func resolveResourceFields(r *http.Request, fields map[string]any) map[string]any {
+	resolved := make(map[string]any, len(fields))
+	for key, value := range fields {
+		if _, missing := value.(missingValue); !missing {
+			resolved[key] = resolveResourceValue(r, value)
+		}
+	}
+	return resolved
+}
+
+func resourceWrap(wrap *string) string {
+	if wrap != nil {
+		return *wrap
+	}
+	return defaultResourceWrap
+}
+
+func mergeResourceMembers[V any](members, more map[string]V) map[string]V {
+	if members == nil {
+		members = make(map[string]V, len(more))
+	}
+	for key, value := range more {
+		members[key] = value
+	}
+	return members
+}
@@ -0,0 +1,198 @@
+package response
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type resourcePost struct {
+	ID    int
+	Title string
+}
+
+type resourceUser struct {
+	ID       int
+	Name     string
+	Password string
+	Admin    bool
+	Posts    []resourcePost
+	Manager  *resourceUser
+}
+
+var resourcePosts = NewResource(func(r *http.Request, post resourcePost) Fields {
+	return Fields{"id": post.ID, "title": post.Title}
+})
+
+var resourceUsers = NewResource(func(r *http.Request, user resourceUser) Fields {
+	fields := Fields{
+		"id":    user.ID,
+		"name":  user.Name,
+		"admin": When(user.Admin, true),
+		"role":  When(user.Admin, "admin", "member"),
+		"token": WhenFunc(r.URL.Query().Has("token"), func() any { return "secret-" + user.Name }),
+		"posts": WhenFunc(user.Posts != nil, func() any { return resourcePosts.Collection(user.Posts) }),
+	}
+	if user.Manager != nil {
+		fields["manager"] = resourceManagers.Make(*user.Manager)
+	}
+	return fields
+})
+
+var resourceManagers = NewResource(func(r *http.Request, user resourceUser) Fields {
+	return Fields{"id": user.ID, "links": []any{When(user.Admin, "/admin"), "/users"}}
+})
+
+func TestWhenLoaded(t *testing.T) {
+	var posts []resourcePost
+	var manager *resourceUser
+	assert.Equal(t, missingValue{}, WhenLoaded(nil))
+	assert.Equal(t, missingValue{}, WhenLoaded(posts))
+	assert.Equal(t, missingValue{}, WhenLoaded(manager))
+	assert.Equal(t, []resourcePost{}, WhenLoaded([]resourcePost{}))
+	assert.Equal(t, 1, WhenLoaded(1))
+}
+
+func TestResourceResponse(t *testing.T) {
+	user := resourceUser{
+		ID: 1, Name: "alice", Password: "hash", Admin: true,
+		Posts:   []resourcePost{{ID: 7, Title: "Hello"}},
+		Manager: &resourceUser{ID: 2},
+	}
+	recorder := httptest.NewRecorder()
+	resourceUsers.Make(user).WithMeta(map[string]any{"version": 2}).WithLinks(map[string]string{"self": "/users/1"}).
+		ServeHTTP(recorder, httptest.NewRequest("GET", "/users/1?token", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{
+		"data": {
+			"id": 1, "name": "alice", "admin": true, "role": "admin", "token": "secret-alice",
+			"posts": [{"id": 7, "title": "Hello"}],
+			"manager": {"id": 2, "links": ["/users"]}
+		},
+		"meta": {"version": 2},
+		"links": {"self": "/users/1"}
+	}`, recorder.Body.String())
+
+	recorder = httptest.NewRecorder()
+	resourceUsers.Make(resourceUser{ID: 3, Name: "bob"}).SetWrap("").
+		ServeHTTP(recorder, httptest.NewRequest("GET", "/users/3", nil))
+	assert.JSONEq(t, `{"id": 3, "name": "bob", "role": "member"}`, recorder.Body.String())
+}
+
+func TestResourceResponse_TypedSlices(t *testing.T) {
+	resource := NewResource(func(r *http.Request, user resourceUser) Fields {
+		return Fields{
+			"id":      user.ID,
+			"friends": []*ResourceResponse{resourceManagers.Make(resourceUser{ID: 2}), nil},
+			"badges":  []Fields{{"name": "early", "secret": When(false, "x")}, {"name": "admin"}},
+			"scores":  [2]int{3, 4},
+			"avatar":  []byte("png"),
+			"tags":    []string(nil),
+		}
+	})
+	recorder := httptest.NewRecorder()
+	resource.Make(resourceUser{ID: 1}).ServeHTTP(recorder, httptest.NewRequest("GET", "/users/1", nil))
+	assert.JSONEq(t, `{"data": {
+		"id": 1,
+		"friends": [{"id": 2, "links": ["/users"]}, null],
+		"badges": [{"name": "early"}, {"name": "admin"}],
+		"scores": [3, 4],
+		"avatar": "cG5n",
+		"tags": null
+	}}`, recorder.Body.String())
+}
+
+func TestResourceCollectionResponse(t *testing.T) {
+	users := []resourceUser{{ID: 1, Name: "alice"}, {ID: 2, Name: "bob", Posts: []resourcePost{}}}
+	recorder := httptest.NewRecorder()
+	collection := resourceUsers.Collection(users).WithMeta(map[string]any{"count": 2})
+	collection.SetStatusCode(http.StatusPartialContent)
+	collection.ServeHTTP(recorder, httptest.NewRequest("GET", "/users", nil))
+	assert.Equal(t, http.StatusPartialContent, recorder.Code)
+	assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{
+		"data": [
+			{"id": 1, "name": "alice", "role": "member"},
+			{"id": 2, "name": "bob", "role": "member", "posts": []}
+		],
+		"meta": {"count": 2}
+	}`, recorder.Body.String())
+
+	SetDefaultResourceWrap("")
+	defer SetDefaultResourceWrap("data")
+	recorder = httptest.NewRecorder()
+	resourcePosts.Collection(nil).ServeHTTP(recorder, httptest.NewRequest("GET", "/posts", nil))
+	assert.Equal(t, "[]", recorder.Body.String())
+}
+
+func TestResourceCollectionResponse_CollectionFunc(t *testing.T) {
+	yielded := 0
+	collection := resourcePosts.CollectionFunc(func(yield func(resourcePost) bool) {
+		for i := 1; i <= 3; i++ {
+			yielded++
+			if !yield(resourcePost{ID: i, Title: "post"}) {
+				return
+			}
+		}
+	})
+	recorder := httptest.NewRecorder()
+	collection.ServeHTTP(recorder, httptest.NewRequest("GET", "/posts", nil))
+	assert.Equal(t, 3, yielded)
+	assert.Equal(t, `{"data":[{"id":1,"title":"post"},{"id":2,"title":"post"},{"id":3,"title":"post"}]}`,
+		recorder.Body.String())
+
+	collection.SetStatusCode(http.StatusNoContent)
+	result, body := serveOverHTTP(t, collection)
+	assert.Equal(t, http.StatusNoContent, result.StatusCode)
+	assert.Empty(t, body)
+}
+
+func TestResourceCollectionResponse_JSONOptions(t *testing.T) {
+	collection := resourcePosts.Collection([]resourcePost{{ID: 1, Title: "<b>tips</b> & tricks"}})
+	collection.SetOptions(JSONOptions{Indent: "  ", DisableHTMLEscape: true})
+	recorder := httptest.NewRecorder()
+	collection.ServeHTTP(recorder, httptest.NewRequest("GET", "/posts", nil))
+	assert.Equal(t, `{
+  "data": [
+    {
+      "id": 1,
+      "title": "<b>tips</b> & tricks"
+    }
+  ]
+}`, recorder.Body.String())
+}
+
+func TestResourceCollectionResponse_FieldMask(t *testing.T) {
+	users := []resourceUser{{ID: 1, Name: "alice"}, {ID: 2, Name: "bob", Posts: []resourcePost{{ID: 3, Title: "hello"}}}}
+	collection := resourceUsers.Collection(users).WithMeta(map[string]any{"count": 2})
+	collection.FieldsFromQuery().SetStrictFields(true)
+	recorder := httptest.NewRecorder()
+	collection.ServeHTTP(recorder, httptest.NewRequest("GET", "/users?fields=id,posts(title)", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.JSONEq(t, `{
+		"data": [{"id": 1}, {"id": 2, "posts": [{"title": "hello"}]}],
+		"meta": {"count": 2}
+	}`, recorder.Body.String())
+
+	recorder = httptest.NewRecorder()
+	collection.ServeHTTP(recorder, httptest.NewRequest("GET", "/users?fields=id,posts(", nil))
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	assert.Equal(t, "application/problem+json", recorder.Header().Get("Content-Type"))
+}
+
+func TestResourceResponse_FieldMask(t *testing.T) {
+	user := resourceUser{ID: 1, Name: "alice", Manager: &resourceUser{ID: 2}}
+	response := resourceUsers.Make(user).WithMeta(map[string]any{"version": 2})
+	response.FieldsFromQuery().SetStrictFields(true)
+	recorder := httptest.NewRecorder()
+	response.ServeHTTP(recorder, httptest.NewRequest("GET", "/users/1?fields=id,manager.id", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.JSONEq(t, `{"data": {"id": 1, "manager": {"id": 2}}, "meta": {"version": 2}}`, recorder.Body.String())
+
+	recorder = httptest.NewRecorder()
+	response.SetWrap("").ServeHTTP(recorder, httptest.NewRequest("GET", "/users/1?fields=name", nil))
+	assert.JSONEq(t, `{"name": "alice"}`, recorder.Body.String())
+}
@@ -5,6 +5,9 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gopi-frame/exception"
 
@@ -16,7 +19,11 @@ import (
 //   - Status Code: The SetStatusCode method allows you to set the HTTP status code for the response, while the StatusCode method retrieves the current status code.
 //   - Content: The SetContent method sets the response body content, and the Content method retrieves the current content.
 //   - Headers: The SetHeader method allows you to set a specific header value, while SetHeaders sets multiple headers from a map. The HasHeader and Header methods check for the existence of a header and retrieve its value, respectively. The Headers method returns all headers as a [http.Header] instance.
-//   - Cookies: The SetCookie method sets a cookie for the response, and the Cookies method retrieves all cookies associated with the response.
+//   - Cookies: The SetCookie method sets a cookie for the response, the ForgetCookie method tells the client to delete a cookie, and the Cookies method retrieves all cookies associated with the response. Cookies are passed through the [CookiePolicy] set by SetCookiePolicy or [SetDefaultCookiePolicy] before they are sent.
+//   - Security Headers: The SetSecurityHeaders method attaches a [SecurityHeaders] bundle to the response, [SetDefaultSecurityHeaders] attaches one to all responses. [CSPNonce] returns the per-request nonce of the content security policy.
+//   - Caching: The SetCacheControl method sets a [CacheControl] which is sent with a matching Expires header, the CacheControl method parses it back. The Vary and SetAge methods set the Vary and Age headers.
+//   - CORS: The SetCORSPolicy method attaches a [CORSPolicy] to the response, [SetDefaultCORSPolicy] attaches one to all responses.
+//   - Hooks: The BeforeSend, AfterSend and Terminate methods register callbacks which run around the sending of the response, the package functions of the same names register them for all responses.
 //   - Sending Response: The ServeHTTP method is responsible for sending the actual response. It sets the cookies, headers, status code, and writes the content to the provided http.ResponseWriter.
 //
 // The Response struct also provides convenience methods to create specialized response types:
@@ -25,15 +32,30 @@ import (
 //   - Reader: Returns a ReaderResponse instance for streaming data from an [io.Reader].
 //   - Redirect: Returns a RedirectResponse instance for sending an HTTP redirect response.
 //   - File: Returns a FileResponse instance for sending a file as the response body.
+//   - Zip: Returns an ArchiveResponse instance for sending a ZIP archive.
+//   - Tar and TarGz: Return a TarResponse instance for sending a tar or gzip-compressed tar archive.
 //   - Stream: Returns a StreamedResponse instance for sending a streamed response.
+//   - Proxy: Returns a ProxyResponse instance for sending the response of an upstream service.
+//   - View: Returns a ViewResponse instance for sending a page rendered by a [TemplateEngine].
+//   - Validation: Returns a ValidationErrorResponse instance for reporting the fields which failed validation.
+//   - JSONAPI: Returns a JSONAPIResponse instance for sending JSON:API documents.
+//   - HAL: Returns a HALResponse instance for sending HAL and HAL-FORMS documents.
+//   - Paginate: Returns a PaginatedResponse instance for sending a page of a collection with links to the other pages.
 //
 // The [Response] struct serves as the foundation for building and customizing HTTP responses in the application,
 // providing a flexible and extensible approach to handle various response types and requirements.
 type Response struct {
-	headers    http.Header
-	cookies    []*http.Cookie
-	statusCode int
-	content    any
+	headers      http.Header
+	cookies      []*http.Cookie
+	cookiePolicy *CookiePolicy
+	security     *SecurityHeaders
+	cors         *CORSPolicy
+	cacheControl *CacheControl
+	beforeSend   []func(*Response, *http.Request)
+	afterSend    []func(SendResult)
+	terminate    []func(SendResult)
+	statusCode   int
+	content      any
 }
 
 // New creates a new [Response] instance
@@ -108,29 +130,146 @@ func (response *Response) Headers() http.Header {
 	return response.headers
 }
 
+// SetCacheControl sets the Cache-Control header of the response,
+// an Expires header matching the directives is sent with it unless one is set explicitly
+func (response *Response) SetCacheControl(cacheControl *CacheControl) {
+	response.cacheControl = cacheControl
+	response.headers.Del("Cache-Control")
+}
+
+// CacheControl returns the Cache-Control of the response, a header set by SetHeader is parsed,
+// nil is returned when the response has no Cache-Control
+func (response *Response) CacheControl() *CacheControl {
+	if response.cacheControl != nil {
+		return response.cacheControl
+	}
+	if values := response.headers.Values("Cache-Control"); len(values) > 0 {
+		return ParseCacheControl(strings.Join(values, ", "))
+	}
+	return nil
+}
+
+// Vary adds the header names to the Vary header, names already listed are skipped
+func (response *Response) Vary(names ...string) {
+	appendVary(response.headers, names...)
+}
+
+// SetAge sets the Age header, the time the response has been kept in a cache
+func (response *Response) SetAge(age time.Duration) {
+	response.headers.Set("Age", strconv.FormatInt(int64(age/time.Second), 10))
+}
+
 // SetCookie sets cookie to response
 func (response *Response) SetCookie(cookie *http.Cookie) {
 	response.cookies = append(response.cookies, cookie)
 }
 
+// ForgetCookie sets an expired cookie which tells the client to delete the named cookie,
+// the returned cookie can be used to set the path and domain of the cookie to delete.
+// A cookie set before with the same name, path and domain is not sent.
+func (response *Response) ForgetCookie(name string) *http.Cookie {
+	cookie := newForgottenCookie(name)
+	response.SetCookie(cookie)
+	return cookie
+}
+
 // Cookies returns all response cookies
 func (response *Response) Cookies() []*http.Cookie {
 	return response.cookies
 }
 
-// ServeHTTP sends the response
-func (response *Response) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+// SetCookiePolicy sets the cookie policy of the response, it overrides the default cookie policy
+func (response *Response) SetCookiePolicy(policy *CookiePolicy) {
+	response.cookiePolicy = policy
+}
+
+// CookiePolicy returns the cookie policy applied to the response
+func (response *Response) CookiePolicy() *CookiePolicy {
+	if response.cookiePolicy != nil {
+		return response.cookiePolicy
+	}
+	return defaultCookiePolicy
+}
+
+// SetSecurityHeaders sets the security headers of the response, it overrides the default security headers
+func (response *Response) SetSecurityHeaders(headers *SecurityHeaders) {
+	response.security = headers
+}
+
+// SecurityHeaders returns the security headers applied to the response
+func (response *Response) SecurityHeaders() *SecurityHeaders {
+	if response.security != nil {
+		return response.security
+	}
+	return defaultSecurityHeaders
+}
+
+// SetCORSPolicy sets the CORS policy of the response, it overrides the default CORS policy
+func (response *Response) SetCORSPolicy(policy *CORSPolicy) {
+	response.cors = policy
+}
+
+// CORSPolicy returns the CORS policy applied to the response
+func (response *Response) CORSPolicy() *CORSPolicy {
+	if response.cors != nil {
+		return response.cors
+	}
+	return defaultCORSPolicy
+}
+
+// sendHeaders writes the cookies and headers of the response to w
+func (response *Response) sendHeaders(w http.ResponseWriter, r *http.Request) {
+	response.runBeforeSend(r)
+	cookies := response.cookies
+	if policy := response.CookiePolicy(); policy != nil {
+		var err error
+		if cookies, err = policy.Apply(cookies); err != nil {
+			panic(err)
+		}
+	} else {
+		cookies = dedupeCookies(cookies)
+	}
 	// set cookies
-	for _, cookie := range response.cookies {
+	for _, cookie := range cookies {
 		http.SetCookie(w, cookie)
 	}
 	// set headers
 	for key, value := range response.headers {
 		w.Header()[key] = value
 	}
+	// set cache headers
+	if response.cacheControl != nil {
+		response.cacheControl.apply(w.Header(), time.Now())
+	}
+	// set security headers
+	if security := response.SecurityHeaders(); security != nil {
+		var nonce string
+		if security.ContentSecurityPolicy != nil && security.ContentSecurityPolicy.UsesNonce() {
+			if nonce = CSPNonce(r); nonce == "" {
+				nonce = generateNonce()
+			}
+		}
+		security.Apply(w.Header(), nonce)
+	}
+	// set CORS headers
+	if cors := response.CORSPolicy(); cors != nil {
+		cors.Apply(w.Header(), r)
+	}
+}
+
+// ServeHTTP sends the response
+func (response *Response) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	response.send(w, r, response.serve)
+}
+
+func (response *Response) serve(w http.ResponseWriter, r *http.Request) {
+	response.sendHeaders(w, r)
 	// set http status code
 	w.WriteHeader(response.statusCode)
-	// send content
+	// send content, the status may not allow a body
+	if !bodyAllowedForStatus(response.statusCode) {
+		return
+	}
 	if response.content != nil {
 		switch v := response.content.(type) {
 		case []byte:
@@ -208,6 +347,86 @@ func (response *Response) File(file string) *FileResponse {
 	return f
 }
 
+// Zip returns a ZIP archive response implement
+func (response *Response) Zip(filename string) *ArchiveResponse {
+	archive := &ArchiveResponse{
+		Response: response,
+	}
+	archive.SetFilename(filename)
+	return archive
+}
+
+// Tar returns a tar archive response implement
+func (response *Response) Tar(filename string) *TarResponse {
+	t := &TarResponse{
+		StreamedResponse: &StreamedResponse{
+			Response: response,
+		},
+	}
+	t.SetFilename(filename)
+	return t
+}
+
+// TarGz returns a gzip-compressed tar archive response implement
+func (response *Response) TarGz(filename string) *TarResponse {
+	return response.Tar(filename).SetGzip(true)
+}
+
+// Proxy returns a reverse proxy response implement
+func (response *Response) Proxy(target string) *ProxyResponse {
+	p := &ProxyResponse{
+		Response: response,
+	}
+	p.SetTarget(target)
+	return p
+}
+
+// View returns a View response implement, the template engine is set with SetEngine
+func (response *Response) View(name string, data any) *ViewResponse {
+	v := &ViewResponse{
+		Response: response,
+	}
+	v.SetView(name, data)
+	return v
+}
+
+// Validation returns a validation error response implement
+func (response *Response) Validation(errors *ValidationErrors) *ValidationErrorResponse {
+	v := &ValidationErrorResponse{
+		Response: response,
+	}
+	v.SetErrors(errors)
+	return v
+}
+
+// JSONAPI returns a JSON:API response implement
+func (response *Response) JSONAPI(data any) *JSONAPIResponse {
+	jsonAPI := &JSONAPIResponse{
+		JSONResponse: &JSONResponse{Response: response},
+	}
+	jsonAPI.SetContent(data)
+	return jsonAPI
+}
+
+// HAL returns a HAL response implement
+func (response *Response) HAL(resource *HALResource) *HALResponse {
+	hal := &HALResponse{
+		JSONResponse: &JSONResponse{Response: response},
+	}
+	hal.SetResource(resource)
+	return hal
+}
+
+// Paginate returns a paginated response implement
+func (response *Response) Paginate(items any, pagination *Pagination) *PaginatedResponse {
+	paginated := &PaginatedResponse{
+		JSONResponse: &JSONResponse{Response: response},
+	}
+	paginated.SetContent(items)
+	paginated.SetPagination(pagination)
+	return paginated
+}
+
 // Stream returns a Stream response implement
 func (response *Response) Stream(step func(io.Writer) bool) *StreamedResponse {
 	s := &StreamedResponse{
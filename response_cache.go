@@ -0,0 +1,448 @@
+package response
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CacheEntry is a fully rendered response kept by a [CacheStore].
+// An entry with Vary names is a marker stored under the primary key of a request,
+// it tells the cache which request headers select the variant entry.
+type CacheEntry struct {
+	StatusCode                int
+	Header                    http.Header
+	Body                      []byte
+	Vary                      []string
+	StoredAt                  time.Time
+	FreshUntil                time.Time
+	StaleWhileRevalidateUntil time.Time
+	StaleIfErrorUntil         time.Time
+}
+
+// Size returns the approximate memory used by the entry in bytes
+func (entry *CacheEntry) Size() int64 {
+	size := int64(len(entry.Body))
+	for key, values := range entry.Header {
+		size += int64(len(key))
+		for _, value := range values {
+			size += int64(len(value))
+		}
+	}
+	for _, name := range entry.Vary {
+		size += int64(len(name))
+	}
+	return size
+}
+
+// CacheStore is the storage of a [ResponseCache], implementations must be safe for concurrent use.
+// Stores may drop entries at any time, e.g. to stay within a size limit.
+type CacheStore interface {
+	// Get returns the entry stored under key
+	Get(key string) (*CacheEntry, bool)
+	// Set stores the entry under key, replacing any existing entry
+	Set(key string, entry *CacheEntry)
+	// Delete removes the entry stored under key
+	Delete(key string)
+}
+
+// ResponseCache is a shared HTTP cache middleware which stores rendered responses in a [CacheStore].
+// Only GET and HEAD requests are cached, the response must carry an explicit lifetime
+// (s-maxage, max-age or Expires) and must not be marked no-store, no-cache or private, nor set cookies.
+// Responses are keyed by method, host, URL and the request headers named in their Vary header.
+//
+// A stale entry within its stale-while-revalidate window is served while it is refreshed in the background,
+// and a stale entry within its stale-if-error window is served when the handler responds with a server error.
+// Concurrent misses of the same key are coalesced into a single handler call, unless the response varies
+// on request headers whose values differ.
+//
+// Responses are written through to the client as the handler writes them, the copy kept for the cache is bounded
+// by SetMaxEntrySize, and responses which are flushed, e.g. streams and server-sent events, are not cached.
+type ResponseCache struct {
+	store        CacheStore
+	maxEntrySize int64
+	now          func() time.Time
+	mu           sync.Mutex
+	inflight     map[string]*cacheCall
+}
+
+type cacheCall struct {
+	done     chan struct{}
+	request  *http.Request
+	entry    *CacheEntry
+	storable bool
+}
+
+// shares returns true when the response of the call can be sent to r,
+// which is the case when r has the same values as the request of the call for the headers the response varies on
+func (call *cacheCall) shares(r *http.Request, key string) bool {
+	if !call.storable {
+		return false
+	}
+	vary := varyNames(call.entry.Header)
+	return len(vary) == 0 || variantKey(r, key, vary) == variantKey(call.request, key, vary)
+}
+
+// NewResponseCache creates a new [ResponseCache]
+func NewResponseCache(store CacheStore) *ResponseCache {
+	return &ResponseCache{
+		store:    store,
+		now:      time.Now,
+		inflight: make(map[string]*cacheCall),
+	}
+}
+
+// SetMaxEntrySize sets the largest body stored in the cache, zero means no limit.
+// The copy of a larger body is dropped as soon as it passes the limit, the response is still sent in full.
+func (cache *ResponseCache) SetMaxEntrySize(size int64) *ResponseCache {
+	cache.maxEntrySize = size
+	return cache
+}
+
+// Store returns the store of the cache
+func (cache *ResponseCache) Store() CacheStore {
+	return cache.store
+}
+
+// Handler wraps next with the cache
+func (cache *ResponseCache) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cache.serve(w, r, next)
+	})
+}
+
+func (cache *ResponseCache) serve(w http.ResponseWriter, r *http.Request, next http.Handler) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		next.ServeHTTP(w, r)
+		return
+	}
+	requestCacheControl := ParseCacheControl(strings.Join(r.Header.Values("Cache-Control"), ","))
+	if requestCacheControl.Has(CacheNoStore) {
+		next.ServeHTTP(w, r)
+		return
+	}
+	key := cacheKey(r)
+	var stale *CacheEntry
+	if !requestCacheControl.Has(CacheNoCache) && r.Header.Get("Pragma") != "no-cache" {
+		if entry, ok := cache.lookup(r, key); ok {
+			now := cache.now()
+			if now.Before(entry.FreshUntil) {
+				writeCacheEntry(w, r, entry, now)
+				return
+			}
+			if now.Before(entry.StaleWhileRevalidateUntil) {
+				writeCacheEntry(w, r, entry, now)
+				go cache.fetch(nil, r.Clone(context.WithoutCancel(r.Context())), key, next, nil)
+				return
+			}
+			stale = entry
+		}
+	}
+	if requestCacheControl.Has(CacheOnlyIfCached) {
+		w.WriteHeader(http.StatusGatewayTimeout)
+		return
+	}
+	if entry, written := cache.fetch(w, r, key, next, stale); !written {
+		writeCacheEntry(w, r, entry, cache.now())
+	}
+}
+
+// lookup returns the entry of the request, following the variant marker if there is one
+func (cache *ResponseCache) lookup(r *http.Request, key string) (*CacheEntry, bool) {
+	entry, ok := cache.store.Get(key)
+	if !ok || len(entry.Vary) == 0 {
+		return entry, ok
+	}
+	return cache.store.Get(variantKey(r, key, entry.Vary))
+}
+
+// fetch calls the handler, writing its response to w, and stores it. Concurrent calls with the same key wait
+// for the first one and share its response when it is storable and selected by the same varied headers,
+// otherwise they call the handler themselves.
+// The returned entry is the one to send, written is true when it has already been written to w.
+func (cache *ResponseCache) fetch(w http.ResponseWriter, r *http.Request, key string, next http.Handler, stale *CacheEntry) (*CacheEntry, bool) {
+	cache.mu.Lock()
+	if call, ok := cache.inflight[key]; ok {
+		cache.mu.Unlock()
+		<-call.done
+		if call.shares(r, key) {
+			return call.entry, false
+		}
+		entry, storable, written := cache.render(w, r, next, stale)
+		if storable {
+			cache.save(r, key, entry)
+		}
+		return entry, written
+	}
+	call := &cacheCall{done: make(chan struct{}), request: r}
+	cache.inflight[key] = call
+	cache.mu.Unlock()
+
+	defer func() {
+		cache.mu.Lock()
+		delete(cache.inflight, key)
+		cache.mu.Unlock()
+		close(call.done)
+	}()
+	entry, storable, written := cache.render(w, r, next, stale)
+	call.entry, call.storable = entry, storable
+	if storable {
+		cache.save(r, key, entry)
+	}
+	return entry, written
+}
+
+// render calls the handler, its response is written through to w while a copy is kept for the cache.
+// It returns the entry of the response, if the entry can be stored, and if the response has been written to w.
+// A server error is not written when the stale entry can be served in its place, the stale entry is returned instead.
+func (cache *ResponseCache) render(w http.ResponseWriter, r *http.Request, next http.Handler, stale *CacheEntry) (*CacheEntry, bool, bool) {
+	writer := &cacheWriter{ResponseWriter: w, header: make(http.Header), limit: cache.maxEntrySize}
+	writer.start = func(statusCode int) bool {
+		now := cache.now()
+		writer.entry = &CacheEntry{StatusCode: statusCode, Header: writer.header.Clone(), StoredAt: now}
+		if statusCode >= http.StatusInternalServerError && stale != nil && now.Before(stale.StaleIfErrorUntil) {
+			return false
+		}
+		writer.storable = cacheLifetime(r, writer.entry, now)
+		return true
+	}
+	next.ServeHTTP(writer, r)
+	writer.WriteHeader(http.StatusOK)
+	if writer.discarded {
+		return stale, false, false
+	}
+	writer.entry.Body = writer.body.Bytes()
+	return writer.entry, writer.storable && !writer.overflow, w != nil
+}
+
+// save stores the entry, a marker is stored under the primary key when the response varies
+func (cache *ResponseCache) save(r *http.Request, key string, entry *CacheEntry) {
+	vary := varyNames(entry.Header)
+	if len(vary) == 0 {
+		cache.store.Set(key, entry)
+		return
+	}
+	cache.store.Set(key, &CacheEntry{Vary: vary, StoredAt: entry.StoredAt})
+	cache.store.Set(variantKey(r, key, vary), entry)
+}
+
+// cacheLifetime fills the freshness of the entry, and returns false when the response must not be stored
+func cacheLifetime(r *http.Request, entry *CacheEntry, now time.Time) bool {
+	if !cacheableStatus(entry.StatusCode) || len(entry.Header.Values("Set-Cookie")) > 0 {
+		return false
+	}
+	if slices.Contains(varyNames(entry.Header), "*") {
+		return false
+	}
+	cacheControl := ParseCacheControl(strings.Join(entry.Header.Values("Cache-Control"), ","))
+	if cacheControl.Has(CacheNoStore) || cacheControl.Has(CacheNoCache) || cacheControl.Has(CachePrivate) {
+		return false
+	}
+	if r.Header.Get("Authorization") != "" && !cacheControl.Has(CachePublic) &&
+		!cacheControl.Has(CacheSMaxAge) && !cacheControl.Has(CacheMustRevalidate) {
+		return false
+	}
+	lifetime, ok := cacheControl.Duration(CacheSMaxAge)
+	if !ok {
+		lifetime, ok = cacheControl.Duration(CacheMaxAge)
+	}
+	if !ok {
+		expires, err := http.ParseTime(entry.Header.Get("Expires"))
+		if err != nil {
+			return false
+		}
+		date, err := http.ParseTime(entry.Header.Get("Date"))
+		if err != nil {
+			date = now
+		}
+		lifetime = expires.Sub(date)
+	}
+	if age, err := strconv.ParseInt(entry.Header.Get("Age"), 10, 64); err == nil && age > 0 {
+		entry.StoredAt = now.Add(-time.Duration(age) * time.Second)
+	}
+	entry.FreshUntil = entry.StoredAt.Add(lifetime)
+	if lifetime <= 0 {
+		return false
+	}
+	if cacheControl.Has(CacheMustRevalidate) || cacheControl.Has(CacheProxyRevalidate) {
+		return true
+	}
+	if staleWhileRevalidate, ok := cacheControl.Duration(CacheStaleWhileRevalidate); ok {
+		entry.StaleWhileRevalidateUntil = entry.FreshUntil.Add(staleWhileRevalidate)
+	}
+	if staleIfError, ok := cacheControl.Duration(CacheStaleIfError); ok {
+		entry.StaleIfErrorUntil = entry.FreshUntil.Add(staleIfError)
+	}
+	return true
+}
+
+func cacheableStatus(statusCode int) bool {
+	switch statusCode {
+	case http.StatusOK, http.StatusNonAuthoritativeInfo, http.StatusNoContent,
+		http.StatusMultipleChoices, http.StatusMovedPermanently, http.StatusPermanentRedirect,
+		http.StatusNotFound, http.StatusMethodNotAllowed, http.StatusGone,
+		http.StatusRequestURITooLong, http.StatusNotImplemented:
+		return true
+	}
+	return false
+}
+
+func cacheKey(r *http.Request) string {
+	return r.Method + " " + r.Host + r.URL.RequestURI()
+}
+
+func variantKey(r *http.Request, key string, vary []string) string {
+	var builder strings.Builder
+	builder.WriteString(key)
+	for _, name := range vary {
+		builder.WriteString("\n")
+		builder.WriteString(name)
+		builder.WriteString(": ")
+		builder.WriteString(strings.Join(r.Header.Values(name), ","))
+	}
+	return builder.String()
+}
+
+// varyNames returns the canonical header names listed in the Vary header
+func varyNames(header http.Header) []string {
+	var names []string
+	for _, value := range header.Values("Vary") {
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, http.CanonicalHeaderKey(name))
+			}
+		}
+	}
+	slices.Sort(names)
+	return slices.Compact(names)
+}
+
+func writeCacheEntry(w http.ResponseWriter, r *http.Request, entry *CacheEntry, now time.Time) {
+	for key, values := range entry.Header {
+		w.Header()[key] = slices.Clone(values)
+	}
+	if !entry.FreshUntil.IsZero() {
+		age := now.Sub(entry.StoredAt)
+		if age < 0 {
+			age = 0
+		}
+		w.Header().Set("Age", strconv.FormatInt(int64(age/time.Second), 10))
+	}
+	w.WriteHeader(entry.StatusCode)
+	if r.Method == http.MethodHead || len(entry.Body) == 0 {
+		return
+	}
+	if _, err := w.Write(entry.Body); err != nil {
+		panic(err)
+	}
+}
+
+// cacheWriter writes a response through to the client and keeps a copy of it for the cache.
+// The copy is dropped when the body exceeds the limit, when the response is flushed, or when it can not be stored.
+// Without a wrapped writer, e.g. when a stale entry is revalidated in the background, the response is only copied.
+type cacheWriter struct {
+	http.ResponseWriter
+	header      http.Header
+	wroteHeader bool
+	// start is called with the status code before it is written, false discards the response
+	start     func(statusCode int) bool
+	entry     *CacheEntry
+	storable  bool
+	discarded bool
+	body      bytes.Buffer
+	limit     int64
+	overflow  bool
+}
+
+func (writer *cacheWriter) Header() http.Header {
+	return writer.header
+}
+
+func (writer *cacheWriter) WriteHeader(statusCode int) {
+	if writer.wroteHeader {
+		return
+	}
+	writer.wroteHeader = true
+	writer.discarded = !writer.start(statusCode)
+	if writer.discarded || writer.ResponseWriter == nil {
+		return
+	}
+	for key, values := range writer.header {
+		writer.ResponseWriter.Header()[key] = values
+	}
+	writer.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (writer *cacheWriter) Write(b []byte) (int, error) {
+	writer.WriteHeader(http.StatusOK)
+	if writer.storable && !writer.overflow {
+		if writer.limit > 0 && int64(writer.body.Len()+len(b)) > writer.limit {
+			writer.stopCopy()
+		} else {
+			writer.body.Write(b)
+		}
+	}
+	if writer.discarded || writer.ResponseWriter == nil {
+		return len(b), nil
+	}
+	return writer.ResponseWriter.Write(b)
+}
+
+// Flush sends the buffered data to the client, a flushed response is streamed and not cached
+func (writer *cacheWriter) Flush() {
+	writer.WriteHeader(http.StatusOK)
+	writer.stopCopy()
+	if writer.discarded || writer.ResponseWriter == nil {
+		return
+	}
+	if flusher, ok := writer.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap returns the wrapped writer for [http.ResponseController]
+func (writer *cacheWriter) Unwrap() http.ResponseWriter {
+	return writer.ResponseWriter
+}
+
+// stopCopy drops the copy of the body, the response is not cached
+func (writer *cacheWriter) stopCopy() {
+	writer.overflow = true
+	writer.body = bytes.Buffer{}
+}
+
+// bufferedResponseWriter keeps a rendered response in memory
+type bufferedResponseWriter struct {
+	header      http.Header
+	statusCode  int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func newBufferedResponseWriter() *bufferedResponseWriter {
+	return &bufferedResponseWriter{header: make(http.Header), statusCode: http.StatusOK}
+}
+
+func (writer *bufferedResponseWriter) Header() http.Header {
+	return writer.header
+}
+
+func (writer *bufferedResponseWriter) WriteHeader(statusCode int) {
+	if writer.wroteHeader {
+		return
+	}
+	writer.wroteHeader = true
+	writer.statusCode = statusCode
+}
+
+func (writer *bufferedResponseWriter) Write(b []byte) (int, error) {
+	writer.WriteHeader(http.StatusOK)
+	return writer.body.Write(b)
+}
+
+func (writer *bufferedResponseWriter) Flush() {}
@@ -0,0 +1,339 @@
+package response
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (clock *fakeClock) Now() time.Time {
+	clock.mu.Lock()
+	defer clock.mu.Unlock()
+	return clock.now
+}
+
+func (clock *fakeClock) Advance(d time.Duration) {
+	clock.mu.Lock()
+	defer clock.mu.Unlock()
+	clock.now = clock.now.Add(d)
+}
+
+func newTestResponseCache() (*ResponseCache, *fakeClock) {
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	cache := NewResponseCache(NewMemoryCacheStore(1 << 20))
+	cache.now = clock.Now
+	return cache, clock
+}
+
+func serveCached(handler http.Handler, request *http.Request) (*http.Response, string) {
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	result := recorder.Result()
+	body, _ := io.ReadAll(result.Body)
+	_ = result.Body.Close()
+	return result, string(body)
+}
+
+func TestResponseCache(t *testing.T) {
+	t.Run("FreshHit", func(t *testing.T) {
+		cache, clock := newTestResponseCache()
+		var calls int32
+		handler := cache.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			n := atomic.AddInt32(&calls, 1)
+			response := New(200).JSON(map[string]int32{"call": n})
+			response.SetCacheControl(NewCacheControl().Public().SMaxAge(time.Minute).MaxAge(time.Second))
+			response.ServeHTTP(w, r)
+		}))
+
+		_, body := serveCached(handler, httptest.NewRequest("GET", "/items", nil))
+		assert.JSONEq(t, `{"call":1}`, body)
+		clock.Advance(30 * time.Second)
+		result, body := serveCached(handler, httptest.NewRequest("GET", "/items", nil))
+		assert.JSONEq(t, `{"call":1}`, body)
+		assert.Equal(t, "30", result.Header.Get("Age"))
+		assert.Equal(t, "application/json", result.Header.Get("Content-Type"))
+
+		clock.Advance(31 * time.Second)
+		_, body = serveCached(handler, httptest.NewRequest("GET", "/items", nil))
+		assert.JSONEq(t, `{"call":2}`, body)
+
+		_, body = serveCached(handler, httptest.NewRequest("GET", "/other", nil))
+		assert.JSONEq(t, `{"call":3}`, body)
+	})
+
+	t.Run("NotStorable", func(t *testing.T) {
+		for _, cacheControl := range []string{"no-store", "private, max-age=60", "no-cache", ""} {
+			cache, _ := newTestResponseCache()
+			var calls int32
+			handler := cache.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&calls, 1)
+				response := New(200, "ok")
+				response.SetHeader("Cache-Control", cacheControl)
+				response.ServeHTTP(w, r)
+			}))
+			serveCached(handler, httptest.NewRequest("GET", "/", nil))
+			serveCached(handler, httptest.NewRequest("GET", "/", nil))
+			assert.Equal(t, int32(2), calls, cacheControl)
+		}
+	})
+
+	t.Run("Vary", func(t *testing.T) {
+		cache, _ := newTestResponseCache()
+		var calls int32
+		handler := cache.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&calls, 1)
+			response := New(200, "lang="+r.Header.Get("Accept-Language"))
+			response.SetCacheControl(NewCacheControl().MaxAge(time.Minute))
+			response.Vary("Accept-Language")
+			response.ServeHTTP(w, r)
+		}))
+		newRequest := func(lang string) *http.Request {
+			request := httptest.NewRequest("GET", "/", nil)
+			request.Header.Set("Accept-Language", lang)
+			return request
+		}
+		_, body := serveCached(handler, newRequest("en"))
+		assert.Equal(t, "lang=en", body)
+		_, body = serveCached(handler, newRequest("fr"))
+		assert.Equal(t, "lang=fr", body)
+		_, body = serveCached(handler, newRequest("en"))
+		assert.Equal(t, "lang=en", body)
+		assert.Equal(t, int32(2), calls)
+	})
+
+	t.Run("StaleWhileRevalidate", func(t *testing.T) {
+		cache, clock := newTestResponseCache()
+		var calls int32
+		refreshed := make(chan struct{}, 1)
+		handler := cache.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			n := atomic.AddInt32(&calls, 1)
+			response := New(200, fmt.Sprintf("call %d", n))
+			response.SetCacheControl(NewCacheControl().MaxAge(time.Minute).StaleWhileRevalidate(time.Minute))
+			response.ServeHTTP(w, r)
+			if n > 1 {
+				refreshed <- struct{}{}
+			}
+		}))
+		serveCached(handler, httptest.NewRequest("GET", "/", nil))
+		clock.Advance(90 * time.Second)
+		_, body := serveCached(handler, httptest.NewRequest("GET", "/", nil))
+		assert.Equal(t, "call 1", body)
+		select {
+		case <-refreshed:
+		case <-time.After(time.Second):
+			assert.FailNow(t, "background refresh did not run")
+		}
+		assert.Eventually(t, func() bool {
+			_, body := serveCached(handler, httptest.NewRequest("GET", "/", nil))
+			return body == "call 2"
+		}, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("StaleIfError", func(t *testing.T) {
+		cache, clock := newTestResponseCache()
+		var calls int32
+		handler := cache.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if atomic.AddInt32(&calls, 1) > 1 {
+				New(http.StatusServiceUnavailable, "down").ServeHTTP(w, r)
+				return
+			}
+			response := New(200, "ok")
+			response.SetCacheControl(NewCacheControl().MaxAge(time.Minute).StaleIfError(time.Hour))
+			response.ServeHTTP(w, r)
+		}))
+		serveCached(handler, httptest.NewRequest("GET", "/", nil))
+		clock.Advance(2 * time.Minute)
+		result, body := serveCached(handler, httptest.NewRequest("GET", "/", nil))
+		assert.Equal(t, 200, result.StatusCode)
+		assert.Equal(t, "ok", body)
+		assert.Equal(t, int32(2), calls)
+	})
+
+	t.Run("Coalescing", func(t *testing.T) {
+		cache, _ := newTestResponseCache()
+		var calls int32
+		release := make(chan struct{})
+		handler := cache.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&calls, 1)
+			<-release
+			response := New(200, "ok")
+			response.SetCacheControl(NewCacheControl().MaxAge(time.Minute))
+			response.ServeHTTP(w, r)
+		}))
+		var wg sync.WaitGroup
+		bodies := make([]string, 5)
+		for i := range bodies {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				_, bodies[i] = serveCached(handler, httptest.NewRequest("GET", "/", nil))
+			}(i)
+		}
+		assert.Eventually(t, func() bool { return atomic.LoadInt32(&calls) == 1 }, time.Second, time.Millisecond)
+		time.Sleep(20 * time.Millisecond)
+		close(release)
+		wg.Wait()
+		assert.Equal(t, int32(1), calls)
+		assert.Equal(t, []string{"ok", "ok", "ok", "ok", "ok"}, bodies)
+	})
+
+	t.Run("CoalescingVary", func(t *testing.T) {
+		cache, _ := newTestResponseCache()
+		var calls int32
+		release := make(chan struct{})
+		handler := cache.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if atomic.AddInt32(&calls, 1) == 1 {
+				<-release
+			}
+			response := New(200, r.Header.Get("Accept-Language"))
+			response.SetCacheControl(NewCacheControl().MaxAge(time.Minute))
+			response.Vary("Accept-Language")
+			response.ServeHTTP(w, r)
+		}))
+		languages := []string{"en", "fr", "en", "fr"}
+		bodies := make([]string, len(languages))
+		var wg sync.WaitGroup
+		serve := func(i int) {
+			defer wg.Done()
+			request := httptest.NewRequest("GET", "/", nil)
+			request.Header.Set("Accept-Language", languages[i])
+			_, bodies[i] = serveCached(handler, request)
+		}
+		wg.Add(len(languages))
+		go serve(0)
+		assert.Eventually(t, func() bool { return atomic.LoadInt32(&calls) == 1 }, time.Second, time.Millisecond)
+		for i := 1; i < len(languages); i++ {
+			go serve(i)
+		}
+		time.Sleep(20 * time.Millisecond)
+		close(release)
+		wg.Wait()
+		assert.Equal(t, languages, bodies)
+
+		request := httptest.NewRequest("GET", "/", nil)
+		request.Header.Set("Accept-Language", "fr")
+		_, body := serveCached(handler, request)
+		assert.Equal(t, "fr", body)
+	})
+
+	t.Run("MaxEntrySize", func(t *testing.T) {
+		cache, _ := newTestResponseCache()
+		cache.SetMaxEntrySize(4)
+		var calls int32
+		handler := cache.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&calls, 1)
+			response := New(200, r.URL.Query().Get("body"))
+			response.SetCacheControl(NewCacheControl().MaxAge(time.Minute))
+			response.ServeHTTP(w, r)
+		}))
+		for i := 0; i < 2; i++ {
+			_, body := serveCached(handler, httptest.NewRequest("GET", "/?body=too+large", nil))
+			assert.Equal(t, "too large", body)
+		}
+		assert.Equal(t, int32(2), calls)
+
+		for i := 0; i < 2; i++ {
+			_, body := serveCached(handler, httptest.NewRequest("GET", "/?body=ok", nil))
+			assert.Equal(t, "ok", body)
+		}
+		assert.Equal(t, int32(3), calls)
+	})
+
+	t.Run("Streaming", func(t *testing.T) {
+		cache, _ := newTestResponseCache()
+		var calls int32
+		release := make(chan struct{})
+		handler := cache.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&calls, 1)
+			response := New(200).Stream(func(w io.Writer) bool {
+				_, _ = w.Write([]byte("first"))
+				w.(http.Flusher).Flush()
+				<-release
+				_, _ = w.Write([]byte(",second"))
+				return false
+			})
+			response.SetCacheControl(NewCacheControl().MaxAge(time.Minute))
+			response.ServeHTTP(w, r)
+		}))
+		server := httptest.NewServer(handler)
+		defer server.Close()
+
+		result, err := http.Get(server.URL)
+		if !assert.Nil(t, err) {
+			return
+		}
+		first := make([]byte, 5)
+		_, err = io.ReadFull(result.Body, first)
+		assert.Nil(t, err)
+		// the first chunk reaches the client while the handler is still running
+		assert.Equal(t, "first", string(first))
+		close(release)
+		rest, _ := io.ReadAll(result.Body)
+		_ = result.Body.Close()
+		assert.Equal(t, ",second", string(rest))
+
+		_, body := serveCached(handler, httptest.NewRequest("GET", "/", nil))
+		assert.Equal(t, "first,second", body)
+		assert.Equal(t, int32(2), calls)
+	})
+
+	t.Run("RequestDirectives", func(t *testing.T) {
+		cache, _ := newTestResponseCache()
+		var calls int32
+		handler := cache.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&calls, 1)
+			response := New(200, "ok")
+			response.SetCacheControl(NewCacheControl().MaxAge(time.Minute))
+			response.ServeHTTP(w, r)
+		}))
+		request := httptest.NewRequest("GET", "/missing", nil)
+		request.Header.Set("Cache-Control", "only-if-cached")
+		result, _ := serveCached(handler, request)
+		assert.Equal(t, http.StatusGatewayTimeout, result.StatusCode)
+
+		serveCached(handler, httptest.NewRequest("GET", "/", nil))
+		request = httptest.NewRequest("GET", "/", nil)
+		request.Header.Set("Cache-Control", "no-cache")
+		serveCached(handler, request)
+		assert.Equal(t, int32(2), calls)
+
+		serveCached(handler, httptest.NewRequest("POST", "/", nil))
+		assert.Equal(t, int32(3), calls)
+	})
+}
+
+func TestMemoryCacheStore(t *testing.T) {
+	store := NewMemoryCacheStore(30)
+	store.Set("a", &CacheEntry{Body: []byte("0123456789")})
+	store.Set("b", &CacheEntry{Body: []byte("0123456789")})
+	assert.Equal(t, 2, store.Len())
+	assert.Equal(t, int64(22), store.Size())
+
+	_, ok := store.Get("a")
+	assert.True(t, ok)
+	store.Set("c", &CacheEntry{Body: []byte("0123456789")})
+	_, ok = store.Get("b")
+	assert.False(t, ok)
+	_, ok = store.Get("a")
+	assert.True(t, ok)
+
+	store.Set("d", &CacheEntry{Body: make([]byte, 100)})
+	_, ok = store.Get("d")
+	assert.False(t, ok)
+
+	store.Delete("a")
+	assert.Equal(t, 1, store.Len())
+	assert.Equal(t, int64(11), store.Size())
+}
@@ -0,0 +1,190 @@
+package responsetest
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// JSON decodes the response body into v
+func (response *TestResponse) JSON(v any) *TestResponse {
+	response.t.Helper()
+	if err := json.Unmarshal(response.body, v); err != nil {
+		response.t.Errorf("expected a JSON body: %v\nbody: %s", err, truncate(response.body, 1024))
+	}
+	return response
+}
+
+// AssertJSON asserts the body is JSON equal to expected, a string or a byte slice is a JSON document
+// and any other value is encoded to JSON
+func (response *TestResponse) AssertJSON(expected any) *TestResponse {
+	response.t.Helper()
+	actual, ok := response.decodeJSON()
+	if !ok {
+		return response
+	}
+	want, err := normalizeJSONDocument(expected)
+	if err != nil {
+		response.t.Errorf("encode expected value: %v", err)
+		return response
+	}
+	assert.Equal(response.t, want, actual, "JSON body")
+	return response
+}
+
+// AssertJSONPath asserts the value at a dot separated path of the JSON body,
+// numeric segments index arrays, e.g. "data.0.id".
+// The expected value is compared with the decoded JSON value after being encoded to JSON itself,
+// so AssertJSONPath("count", 5) matches `{"count": 5}`.
+func (response *TestResponse) AssertJSONPath(path string, expected any) *TestResponse {
+	response.t.Helper()
+	document, ok := response.decodeJSON()
+	if !ok {
+		return response
+	}
+	actual, err := jsonPath(document, path)
+	if err != nil {
+		response.t.Errorf("JSON path %q: %v\nbody: %s", path, err, truncate(response.body, 1024))
+		return response
+	}
+	want, err := normalizeJSON(expected)
+	if err != nil {
+		response.t.Errorf("encode expected value: %v", err)
+		return response
+	}
+	assert.Equal(response.t, want, actual, "JSON path %q", path)
+	return response
+}
+
+// AssertJSONMissingPath asserts the path does not exist in the JSON body
+func (response *TestResponse) AssertJSONMissingPath(path string) *TestResponse {
+	response.t.Helper()
+	document, ok := response.decodeJSON()
+	if !ok {
+		return response
+	}
+	if actual, err := jsonPath(document, path); err == nil {
+		response.t.Errorf("expected JSON path %q to be missing, got %v", path, actual)
+	}
+	return response
+}
+
+// AssertJSONSubset asserts the JSON body contains expected, which is handled like in [TestResponse.AssertJSON],
+// objects may have more members than expected,
+// arrays must have the same length and their elements are compared as subsets
+func (response *TestResponse) AssertJSONSubset(expected any) *TestResponse {
+	response.t.Helper()
+	actual, ok := response.decodeJSON()
+	if !ok {
+		return response
+	}
+	want, err := normalizeJSONDocument(expected)
+	if err != nil {
+		response.t.Errorf("encode expected value: %v", err)
+		return response
+	}
+	if path, ok := jsonSubset(want, actual, "$"); !ok {
+		wantJSON, _ := json.MarshalIndent(want, "", "  ")
+		actualJSON, _ := json.MarshalIndent(actual, "", "  ")
+		response.t.Errorf("JSON body does not contain the expected subset, first difference at %s\nexpected subset:\n%s\nactual:\n%s",
+			path, wantJSON, actualJSON)
+	}
+	return response
+}
+
+func (response *TestResponse) decodeJSON() (any, bool) {
+	response.t.Helper()
+	var document any
+	if err := json.Unmarshal(response.body, &document); err != nil {
+		response.t.Errorf("expected a JSON body: %v\nbody: %s", err, truncate(response.body, 1024))
+		return nil, false
+	}
+	return document, true
+}
+
+// normalizeJSONDocument returns the generic decoded form of value, strings and byte slices are treated as JSON documents
+func normalizeJSONDocument(value any) (any, error) {
+	switch v := value.(type) {
+	case string:
+		return normalizeJSON(json.RawMessage(v))
+	case []byte:
+		return normalizeJSON(json.RawMessage(v))
+	default:
+		return normalizeJSON(value)
+	}
+}
+
+// normalizeJSON returns the generic decoded form of value encoded to JSON
+func normalizeJSON(value any) (any, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var normalized any
+	err = json.Unmarshal(data, &normalized)
+	return normalized, err
+}
+
+func jsonPath(document any, path string) (any, error) {
+	current := document
+	if path == "" {
+		return current, nil
+	}
+	for i, segment := range strings.Split(path, ".") {
+		switch node := current.(type) {
+		case map[string]any:
+			value, ok := node[segment]
+			if !ok {
+				return nil, fmt.Errorf("member %q not found at %q", segment, strings.Join(strings.Split(path, ".")[:i], "."))
+			}
+			current = value
+		case []any:
+			index, err := strconv.Atoi(segment)
+			if err != nil || index < 0 || index >= len(node) {
+				return nil, fmt.Errorf("index %q out of range of array with %d elements", segment, len(node))
+			}
+			current = node[index]
+		default:
+			return nil, fmt.Errorf("can not select %q from %T", segment, current)
+		}
+	}
+	return current, nil
+}
+
+// jsonSubset reports if expected is a subset of actual, and the path of the first difference
+func jsonSubset(expected, actual any, path string) (string, bool) {
+	switch want := expected.(type) {
+	case map[string]any:
+		got, ok := actual.(map[string]any)
+		if !ok {
+			return path, false
+		}
+		for key, value := range want {
+			member, ok := got[key]
+			if !ok {
+				return path + "." + key, false
+			}
+			if p, ok := jsonSubset(value, member, path+"."+key); !ok {
+				return p, false
+			}
+		}
+		return "", true
+	case []any:
+		got, ok := actual.([]any)
+		if !ok || len(got) != len(want) {
+			return path, false
+		}
+		for i := range want {
+			if p, ok := jsonSubset(want[i], got[i], fmt.Sprintf("%s[%d]", path, i)); !ok {
+				return p, false
+			}
+		}
+		return "", true
+	default:
+		return path, reflect.DeepEqual(expected, actual)
+	}
+}
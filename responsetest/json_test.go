@@ -0,0 +1,81 @@
+package responsetest
+
+import (
+	"testing"
+
+	"github.com/gopi-frame/response"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTestResponse_AssertJSONPath(t *testing.T) {
+	resp := response.New(200).JSON(map[string]any{
+		"data": []map[string]any{
+			{"id": 5, "name": "John", "tags": []string{"a", "b"}},
+			{"id": 6, "name": "Jane"},
+		},
+		"meta": map[string]any{"total": 2},
+	})
+	var decoded map[string]any
+	Serve(t, resp).
+		AssertJSONPath("data.0.id", 5).
+		AssertJSONPath("data.1.name", "Jane").
+		AssertJSONPath("data.0.tags", []string{"a", "b"}).
+		AssertJSONPath("meta", map[string]int{"total": 2}).
+		AssertJSONMissingPath("data.2").
+		AssertJSONMissingPath("meta.page").
+		JSON(&decoded)
+	assert.Contains(t, decoded, "data")
+
+	recorder := &recordingT{TB: t}
+	Serve(recorder, resp).
+		AssertJSONPath("data.0.id", 6).
+		AssertJSONPath("data.5.id", 5).
+		AssertJSONPath("meta.total.value", 2).
+		AssertJSONMissingPath("meta.total")
+	assert.Len(t, recorder.errors, 4)
+	assert.Contains(t, recorder.errors[1], "out of range of array with 2 elements")
+
+	recorder = &recordingT{TB: t}
+	Serve(recorder, response.New(200, "not json")).AssertJSONPath("data", 1)
+	assert.Len(t, recorder.errors, 1)
+	assert.Contains(t, recorder.errors[0], "expected a JSON body")
+}
+
+func TestTestResponse_AssertJSON(t *testing.T) {
+	resp := response.New(200).JSON(map[string]any{"id": 1, "name": "John"})
+	Serve(t, resp).AssertJSON(map[string]any{"name": "John", "id": 1})
+
+	recorder := &recordingT{TB: t}
+	Serve(recorder, resp).AssertJSON(map[string]any{"id": 1})
+	assert.Len(t, recorder.errors, 1)
+
+	Serve(t, resp).
+		AssertJSON(`{"name": "John", "id": 1}`).
+		AssertJSON([]byte(`{"id":1,"name":"John"}`)).
+		AssertJSONSubset(`{"id": 1}`)
+
+	recorder = &recordingT{TB: t}
+	Serve(recorder, resp).AssertJSON(`{"id": 2, "name": "John"}`)
+	assert.Len(t, recorder.errors, 1)
+}
+
+func TestTestResponse_AssertJSONSubset(t *testing.T) {
+	resp := response.New(200).JSON(map[string]any{
+		"data": []map[string]any{{"id": 1, "name": "John", "email": "john@example.com"}},
+		"meta": map[string]any{"total": 1, "page": 1},
+	})
+	Serve(t, resp).AssertJSONSubset(map[string]any{
+		"data": []map[string]any{{"id": 1}},
+		"meta": map[string]any{"total": 1},
+	})
+
+	recorder := &recordingT{TB: t}
+	Serve(recorder, resp).
+		AssertJSONSubset(map[string]any{"data": []map[string]any{{"id": 2}}}).
+		AssertJSONSubset(map[string]any{"links": map[string]any{}}).
+		AssertJSONSubset(map[string]any{"data": []any{}})
+	assert.Len(t, recorder.errors, 3)
+	assert.Contains(t, recorder.errors[0], "first difference at $.data[0].id")
+	assert.Contains(t, recorder.errors[1], "first difference at $.links")
+	assert.Contains(t, recorder.errors[2], "first difference at $.data")
+}
@@ -0,0 +1,240 @@
+// Package responsetest provides utilities for testing handlers and responses of the response package.
+//
+// A response is served with [Serve] and the recorded result is checked with fluent assertions:
+//
+//	responsetest.Serve(t, response.New(200).JSON(data)).
+//		AssertStatus(200).
+//		AssertHeader("Content-Type", "application/json").
+//		AssertJSONPath("data.0.id", 5)
+//
+// Failed assertions are reported with t.Errorf, so every assertion of a chain is checked.
+package responsetest
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestResponse is the recorded result of a served response
+type TestResponse struct {
+	t       testing.TB
+	request *http.Request
+	result  *http.Response
+	body    []byte
+}
+
+// Serve serves the handler with request, or with a GET request of "/" when no request is given,
+// and returns the recorded result
+func Serve(t testing.TB, handler http.Handler, request ...*http.Request) *TestResponse {
+	t.Helper()
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	if len(request) > 0 && request[0] != nil {
+		r = request[0]
+	}
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, r)
+	result := recorder.Result()
+	body, err := io.ReadAll(result.Body)
+	if err != nil {
+		t.Fatalf("read response body: %v", err)
+	}
+	if err := result.Body.Close(); err != nil {
+		t.Fatalf("close response body: %v", err)
+	}
+	return &TestResponse{t: t, request: r, result: result, body: body}
+}
+
+// Request returns the served request
+func (response *TestResponse) Request() *http.Request {
+	return response.request
+}
+
+// Result returns the recorded [http.Response], its body is already consumed, see Body
+func (response *TestResponse) Result() *http.Response {
+	return response.result
+}
+
+// Body returns the response body
+func (response *TestResponse) Body() []byte {
+	return response.body
+}
+
+// BodyString returns the response body as string
+func (response *TestResponse) BodyString() string {
+	return string(response.body)
+}
+
+// AssertStatus asserts the response status code
+func (response *TestResponse) AssertStatus(statusCode int) *TestResponse {
+	response.t.Helper()
+	if response.result.StatusCode != statusCode {
+		response.t.Errorf("expected status %d %s, got %d %s\nbody: %s",
+			statusCode, http.StatusText(statusCode),
+			response.result.StatusCode, http.StatusText(response.result.StatusCode),
+			truncate(response.body, 512))
+	}
+	return response
+}
+
+// AssertHeader asserts the value of a header, all values of a repeated header are compared
+func (response *TestResponse) AssertHeader(key string, value ...string) *TestResponse {
+	response.t.Helper()
+	actual := response.result.Header.Values(key)
+	if len(actual) == 0 {
+		response.t.Errorf("expected header %q to be present, got headers:\n%s", key, formatHeader(response.result.Header))
+		return response
+	}
+	if len(value) > 0 {
+		assert.Equal(response.t, value, actual, "header %q", key)
+	}
+	return response
+}
+
+// AssertHeaderMissing asserts the header is not present
+func (response *TestResponse) AssertHeaderMissing(key string) *TestResponse {
+	response.t.Helper()
+	if values := response.result.Header.Values(key); len(values) > 0 {
+		response.t.Errorf("expected header %q to be missing, got %q", key, values)
+	}
+	return response
+}
+
+// Cookie returns the last cookie set with the name
+func (response *TestResponse) Cookie(name string) *http.Cookie {
+	var found *http.Cookie
+	for _, cookie := range response.result.Cookies() {
+		if cookie.Name == name {
+			found = cookie
+		}
+	}
+	return found
+}
+
+// AssertCookie asserts the cookie is set, and its value when value is given
+func (response *TestResponse) AssertCookie(name string, value ...string) *TestResponse {
+	response.t.Helper()
+	cookie := response.Cookie(name)
+	if cookie == nil {
+		names := make([]string, 0)
+		for _, c := range response.result.Cookies() {
+			names = append(names, c.Name)
+		}
+		response.t.Errorf("expected cookie %q to be set, got cookies %q", name, names)
+		return response
+	}
+	if len(value) > 0 && cookie.Value != value[0] {
+		response.t.Errorf("expected cookie %q to have value %q, got %q", name, value[0], cookie.Value)
+	}
+	return response
+}
+
+// AssertCookieExpired asserts the cookie is set and tells the client to delete it
+func (response *TestResponse) AssertCookieExpired(name string) *TestResponse {
+	response.t.Helper()
+	cookie := response.Cookie(name)
+	if cookie == nil {
+		response.t.Errorf("expected cookie %q to be set", name)
+		return response
+	}
+	if cookie.MaxAge >= 0 && (cookie.Expires.IsZero() || cookie.Expires.Unix() > 1) {
+		response.t.Errorf("expected cookie %q to be expired, got %q", name, cookie.String())
+	}
+	return response
+}
+
+// AssertRedirectTo asserts the response redirects to location
+func (response *TestResponse) AssertRedirectTo(location string) *TestResponse {
+	response.t.Helper()
+	if response.result.StatusCode < 300 || response.result.StatusCode > 399 {
+		response.t.Errorf("expected a redirect status, got %d %s", response.result.StatusCode, http.StatusText(response.result.StatusCode))
+	}
+	if actual := response.result.Header.Get("Location"); actual != location {
+		response.t.Errorf("expected redirect to %q, got %q", location, actual)
+	}
+	return response
+}
+
+// AssertDownload asserts the response is an attachment, and its filename when filename is given
+func (response *TestResponse) AssertDownload(filename ...string) *TestResponse {
+	response.t.Helper()
+	disposition := response.result.Header.Get("Content-Disposition")
+	mediaType, params, err := mime.ParseMediaType(disposition)
+	if err != nil || mediaType != "attachment" {
+		response.t.Errorf("expected an attachment Content-Disposition, got %q", disposition)
+		return response
+	}
+	if len(filename) > 0 && params["filename"] != filename[0] {
+		response.t.Errorf("expected download filename %q, got %q", filename[0], params["filename"])
+	}
+	return response
+}
+
+// AssertSee asserts the raw body contains the text
+func (response *TestResponse) AssertSee(text string) *TestResponse {
+	response.t.Helper()
+	if !strings.Contains(string(response.body), text) {
+		response.t.Errorf("expected body to contain %q\nbody: %s", text, truncate(response.body, 1024))
+	}
+	return response
+}
+
+// AssertSeeText asserts the text content of an HTML body contains the text,
+// tags are removed, entities are decoded and whitespace is collapsed before comparing
+func (response *TestResponse) AssertSeeText(text string) *TestResponse {
+	response.t.Helper()
+	content := htmlText(string(response.body))
+	if !strings.Contains(content, collapseSpaces(text)) {
+		response.t.Errorf("expected text content to contain %q\ntext: %s", text, truncate([]byte(content), 1024))
+	}
+	return response
+}
+
+// AssertDontSeeText asserts the text content of an HTML body does not contain the text
+func (response *TestResponse) AssertDontSeeText(text string) *TestResponse {
+	response.t.Helper()
+	content := htmlText(string(response.body))
+	if strings.Contains(content, collapseSpaces(text)) {
+		response.t.Errorf("expected text content not to contain %q\ntext: %s", text, truncate([]byte(content), 1024))
+	}
+	return response
+}
+
+var (
+	htmlHiddenPattern = regexp.MustCompile(`(?is)<(script|style|head)\b.*?</(script|style|head)>|<!--.*?-->`)
+	htmlTagPattern    = regexp.MustCompile(`(?s)<[^>]*>`)
+	spacesPattern     = regexp.MustCompile(`\s+`)
+)
+
+func htmlText(content string) string {
+	content = htmlHiddenPattern.ReplaceAllString(content, " ")
+	content = htmlTagPattern.ReplaceAllString(content, " ")
+	return collapseSpaces(html.UnescapeString(content))
+}
+
+func collapseSpaces(text string) string {
+	return strings.TrimSpace(spacesPattern.ReplaceAllString(text, " "))
+}
+
+func formatHeader(header http.Header) string {
+	var builder strings.Builder
+	if err := header.Write(&builder); err != nil {
+		return fmt.Sprint(header)
+	}
+	return builder.String()
+}
+
+func truncate(body []byte, size int) string {
+	if len(body) <= size {
+		return string(body)
+	}
+	return fmt.Sprintf("%s... (%d more bytes)", body[:size], len(body)-size)
+}
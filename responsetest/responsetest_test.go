@@ -0,0 +1,102 @@
+package responsetest
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gopi-frame/response"
+	"github.com/stretchr/testify/assert"
+)
+
+// recordingT records failures instead of failing the test
+type recordingT struct {
+	testing.TB
+	errors []string
+}
+
+func (t *recordingT) Helper() {}
+
+func (t *recordingT) Errorf(format string, args ...any) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+func TestServe(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		response.New(201, r.Method+" "+r.URL.Path).ServeHTTP(w, r)
+	})
+	Serve(t, handler).AssertStatus(201).AssertSee("GET /")
+	Serve(t, handler, httptest.NewRequest("POST", "/items", nil)).AssertSee("POST /items")
+}
+
+func TestTestResponse_AssertStatus(t *testing.T) {
+	recorder := &recordingT{TB: t}
+	Serve(recorder, response.New(404, "missing")).AssertStatus(200)
+	assert.Len(t, recorder.errors, 1)
+	assert.Contains(t, recorder.errors[0], "expected status 200 OK, got 404 Not Found")
+	assert.Contains(t, recorder.errors[0], "body: missing")
+}
+
+func TestTestResponse_AssertHeader(t *testing.T) {
+	resp := response.New(200)
+	resp.SetHeader("X-Request-Id", "abc")
+	Serve(t, resp).
+		AssertHeader("X-Request-Id", "abc").
+		AssertHeader("x-request-id").
+		AssertHeaderMissing("X-Other")
+
+	recorder := &recordingT{TB: t}
+	Serve(recorder, resp).AssertHeader("X-Other").AssertHeaderMissing("X-Request-Id")
+	assert.Len(t, recorder.errors, 2)
+}
+
+func TestTestResponse_AssertCookie(t *testing.T) {
+	resp := response.New(200)
+	resp.SetCookie(&http.Cookie{Name: "theme", Value: "dark"})
+	resp.ForgetCookie("session")
+	Serve(t, resp).
+		AssertCookie("theme").
+		AssertCookie("theme", "dark").
+		AssertCookieExpired("session")
+
+	recorder := &recordingT{TB: t}
+	Serve(recorder, resp).AssertCookie("theme", "light").AssertCookie("missing").AssertCookieExpired("theme")
+	assert.Len(t, recorder.errors, 3)
+	assert.Contains(t, recorder.errors[0], `expected cookie "theme" to have value "light", got "dark"`)
+}
+
+func TestTestResponse_AssertRedirectTo(t *testing.T) {
+	Serve(t, response.New(302).Redirect("/login")).AssertRedirectTo("/login")
+
+	recorder := &recordingT{TB: t}
+	Serve(recorder, response.New(200, "ok")).AssertRedirectTo("/login")
+	assert.Len(t, recorder.errors, 2)
+}
+
+func TestTestResponse_AssertDownload(t *testing.T) {
+	archive := response.New(200).Zip("reports.zip")
+	archive.AddBytes("a.txt", []byte("a"), time.Now())
+	Serve(t, archive).AssertDownload().AssertDownload("reports.zip")
+
+	recorder := &recordingT{TB: t}
+	Serve(recorder, archive).AssertDownload("other.zip")
+	Serve(recorder, response.New(200)).AssertDownload()
+	assert.Len(t, recorder.errors, 2)
+}
+
+func TestTestResponse_AssertSeeText(t *testing.T) {
+	page := `<html><head><title>Ignored</title><style>p{}</style></head>
+<body><h1>Hello,
+   <b>John</b></h1><p>Tom &amp; Jerry</p><script>var secret = 1</script></body></html>`
+	Serve(t, response.New(200, page)).
+		AssertSeeText("Hello, John").
+		AssertSeeText("Tom & Jerry").
+		AssertDontSeeText("secret").
+		AssertDontSeeText("Ignored")
+
+	recorder := &recordingT{TB: t}
+	Serve(recorder, response.New(200, page)).AssertSeeText("Goodbye").AssertDontSeeText("John")
+	assert.Len(t, recorder.errors, 2)
+}
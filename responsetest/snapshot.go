@@ -0,0 +1,183 @@
+package responsetest
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var updateSnapshots = flag.Bool("update-snapshots", false, "update the golden files of responsetest snapshots")
+
+// Masked replaces the masked values of a snapshot
+const Masked = "<masked>"
+
+// SnapshotOptions customizes how a response is serialized by AssertSnapshot.
+//   - Dir: The directory of the golden files, "testdata/snapshots" is used when it is empty.
+//   - MaskHeaders: Headers whose values are replaced with [Masked], e.g. "Date" or "Etag".
+//   - MaskCookies: Cookies whose values are replaced with [Masked].
+//   - MaskJSONPaths: Dot separated paths of a JSON body whose values are replaced with [Masked],
+//     a "*" segment matches every member or element, e.g. "data.*.created_at".
+//   - MaskPatterns: Regular expressions whose matches in the body are replaced with [Masked].
+type SnapshotOptions struct {
+	Dir           string
+	MaskHeaders   []string
+	MaskCookies   []string
+	MaskJSONPaths []string
+	MaskPatterns  []*regexp.Regexp
+}
+
+// AssertSnapshot compares the serialized response with the golden file of the test,
+// the golden file is named after the test and the optional name.
+// Run the tests with -update-snapshots to write the golden files.
+//
+// The snapshot contains the status line, the headers sorted by name, the cookies sorted by name
+// and the body, JSON bodies are indented with their object members sorted.
+func (response *TestResponse) AssertSnapshot(name string, options ...SnapshotOptions) *TestResponse {
+	response.t.Helper()
+	var opts SnapshotOptions
+	if len(options) > 0 {
+		opts = options[0]
+	}
+	snapshot, err := response.Snapshot(opts)
+	if err != nil {
+		response.t.Errorf("snapshot: %v", err)
+		return response
+	}
+	dir := opts.Dir
+	if dir == "" {
+		dir = filepath.Join("testdata", "snapshots")
+	}
+	file := filepath.Join(dir, filepath.FromSlash(response.t.Name()))
+	if name != "" {
+		file += "_" + name
+	}
+	file += ".golden"
+	if *updateSnapshots {
+		if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+			response.t.Errorf("snapshot: %v", err)
+			return response
+		}
+		if err := os.WriteFile(file, []byte(snapshot), 0o644); err != nil {
+			response.t.Errorf("snapshot: %v", err)
+		}
+		return response
+	}
+	golden, err := os.ReadFile(file)
+	if err != nil {
+		response.t.Errorf("snapshot: %v, run the tests with -update-snapshots to create it", err)
+		return response
+	}
+	assert.Equal(response.t, string(golden), snapshot,
+		"snapshot %s does not match, run the tests with -update-snapshots to accept the changes", file)
+	return response
+}
+
+// Snapshot returns the serialized response
+func (response *TestResponse) Snapshot(options SnapshotOptions) (string, error) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%s %s\n", response.result.Proto, response.result.Status)
+
+	maskedHeaders := make(map[string]bool)
+	for _, name := range options.MaskHeaders {
+		maskedHeaders[http.CanonicalHeaderKey(name)] = true
+	}
+	keys := make([]string, 0, len(response.result.Header))
+	for key := range response.result.Header {
+		if key != "Set-Cookie" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		for _, value := range response.result.Header[key] {
+			if maskedHeaders[key] {
+				value = Masked
+			}
+			fmt.Fprintf(&buf, "%s: %s\n", key, value)
+		}
+	}
+
+	maskedCookies := make(map[string]bool)
+	for _, name := range options.MaskCookies {
+		maskedCookies[name] = true
+	}
+	cookies := response.result.Cookies()
+	sort.SliceStable(cookies, func(i, j int) bool { return cookies[i].Name < cookies[j].Name })
+	for _, cookie := range cookies {
+		if maskedCookies[cookie.Name] {
+			cookie.Value = Masked
+		}
+		fmt.Fprintf(&buf, "Set-Cookie: %s\n", cookie.String())
+	}
+
+	body, err := response.normalizedBody(options)
+	if err != nil {
+		return "", err
+	}
+	buf.WriteString("\n")
+	buf.WriteString(body)
+	if body != "" && !strings.HasSuffix(body, "\n") {
+		buf.WriteString("\n")
+	}
+	return buf.String(), nil
+}
+
+func (response *TestResponse) normalizedBody(options SnapshotOptions) (string, error) {
+	body := string(response.body)
+	if strings.Contains(response.result.Header.Get("Content-Type"), "json") && len(response.body) > 0 {
+		// numbers are kept as written, large integers would lose precision as float64
+		var document any
+		decoder := json.NewDecoder(bytes.NewReader(response.body))
+		decoder.UseNumber()
+		if err := decoder.Decode(&document); err != nil {
+			return "", err
+		}
+		for _, path := range options.MaskJSONPaths {
+			document = maskJSONPath(document, strings.Split(path, "."))
+		}
+		var indented bytes.Buffer
+		encoder := json.NewEncoder(&indented)
+		encoder.SetEscapeHTML(false)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(document); err != nil {
+			return "", err
+		}
+		body = indented.String()
+	}
+	for _, pattern := range options.MaskPatterns {
+		body = pattern.ReplaceAllString(body, Masked)
+	}
+	return body, nil
+}
+
+func maskJSONPath(node any, segments []string) any {
+	if len(segments) == 0 {
+		return Masked
+	}
+	segment, rest := segments[0], segments[1:]
+	switch v := node.(type) {
+	case map[string]any:
+		for key, value := range v {
+			if segment == "*" || segment == key {
+				v[key] = maskJSONPath(value, rest)
+			}
+		}
+	case []any:
+		for i, value := range v {
+			if segment == "*" || segment == strconv.Itoa(i) {
+				v[i] = maskJSONPath(value, rest)
+			}
+		}
+	}
+	return node
+}
@@ -0,0 +1,85 @@
+package responsetest
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/gopi-frame/response"
+	"github.com/stretchr/testify/assert"
+)
+
+func newSnapshotResponse(name string) *response.JSONResponse {
+	resp := response.New(200).JSON(map[string]any{
+		"data": []map[string]any{
+			{"name": name, "id": 1, "created_at": time.Now().Format(time.RFC3339)},
+		},
+		"request_id": "req-" + time.Now().Format("150405.000000"),
+	})
+	resp.SetHeader("X-Request-Id", time.Now().String())
+	resp.SetHeader("X-Version", "1")
+	resp.SetCookie(&http.Cookie{Name: "theme", Value: "dark"})
+	resp.SetCookie(&http.Cookie{Name: "csrf", Value: time.Now().String()})
+	return resp
+}
+
+var snapshotOptions = SnapshotOptions{
+	MaskHeaders:   []string{"x-request-id"},
+	MaskCookies:   []string{"csrf"},
+	MaskJSONPaths: []string{"data.*.created_at"},
+	MaskPatterns:  []*regexp.Regexp{regexp.MustCompile(`req-[0-9.]+`)},
+}
+
+func TestTestResponse_AssertSnapshot(t *testing.T) {
+	Serve(t, newSnapshotResponse("John")).AssertSnapshot("users", snapshotOptions)
+}
+
+func TestTestResponse_AssertSnapshotMismatch(t *testing.T) {
+	options := snapshotOptions
+	options.Dir = t.TempDir()
+
+	*updateSnapshots = true
+	Serve(t, newSnapshotResponse("John")).AssertSnapshot("", options)
+	*updateSnapshots = false
+
+	golden, err := os.ReadFile(filepath.Join(options.Dir, t.Name()+".golden"))
+	assert.Nil(t, err)
+	assert.Contains(t, string(golden), `"name": "John"`)
+
+	Serve(t, newSnapshotResponse("John")).AssertSnapshot("", options)
+
+	recorder := &recordingT{TB: t}
+	Serve(recorder, newSnapshotResponse("Jane")).AssertSnapshot("", options)
+	assert.Len(t, recorder.errors, 1)
+	assert.Contains(t, recorder.errors[0], `-      "name": "John"`)
+	assert.Contains(t, recorder.errors[0], `+      "name": "Jane"`)
+
+	recorder = &recordingT{TB: t}
+	Serve(recorder, newSnapshotResponse("John")).AssertSnapshot("missing", options)
+	assert.Len(t, recorder.errors, 1)
+	assert.Contains(t, recorder.errors[0], "-update-snapshots")
+}
+
+func TestTestResponse_AssertSnapshotLargeNumbers(t *testing.T) {
+	options := SnapshotOptions{Dir: t.TempDir()}
+	newResponse := func(id uint64) *response.JSONResponse {
+		return response.New(200).JSON(map[string]any{"id": id, "price": 12.50})
+	}
+
+	*updateSnapshots = true
+	Serve(t, newResponse(9007199254740993)).AssertSnapshot("", options)
+	*updateSnapshots = false
+
+	golden, err := os.ReadFile(filepath.Join(options.Dir, t.Name()+".golden"))
+	assert.Nil(t, err)
+	assert.Contains(t, string(golden), `"id": 9007199254740993`)
+	assert.Contains(t, string(golden), `"price": 12.5`)
+
+	// 2^53+1 and 2^53 are the same float64
+	recorder := &recordingT{TB: t}
+	Serve(recorder, newResponse(9007199254740992)).AssertSnapshot("", options)
+	assert.Len(t, recorder.errors, 1)
+}
@@ -0,0 +1,253 @@
+package responsetest
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// ErrNoRoute is returned by [Transport] when no route matches a request
+var ErrNoRoute = errors.New("responsetest: no route matches the request")
+
+// RecordedRequest is a request sent through a [Transport]
+type RecordedRequest struct {
+	Method string
+	URL    *url.URL
+	Header http.Header
+	Body   []byte
+}
+
+// Transport is an [http.RoundTripper] which serves requests with handlers, such as the response types
+// of the response package, without opening sockets. Every request is recorded for assertions.
+//
+//	transport := responsetest.NewTransport()
+//	transport.Handle("GET", "/users/{id}", response.New(200).JSON(user))
+//	transport.Handle("POST", "/users", response.New(201), response.New(409)).Times(2)
+//	client := transport.Client()
+//
+// Routes are matched in the order they are registered, skipping exhausted routes.
+type Transport struct {
+	mu       sync.Mutex
+	routes   []*Route
+	requests []*RecordedRequest
+}
+
+// Route is a request matcher of a [Transport] with the handlers serving the matched requests
+type Route struct {
+	mu       *sync.Mutex
+	method   string
+	host     string
+	segments []string
+	matchers []func(*http.Request) bool
+	handlers []http.Handler
+	err      error
+	times    int
+	calls    int
+}
+
+// NewTransport creates a new [Transport]
+func NewTransport() *Transport {
+	return &Transport{}
+}
+
+// Client returns an [http.Client] using the transport
+func (transport *Transport) Client() *http.Client {
+	return &http.Client{Transport: transport}
+}
+
+// Handle registers a route, method is empty to match any method. The pattern is a path or an absolute URL,
+// a "{name}" segment matches any segment and a trailing "{name...}" segment matches the rest of the path,
+// the matched segments are available with [http.Request.PathValue].
+// The handlers serve the matched requests in sequence, the last handler serves all remaining requests.
+func (transport *Transport) Handle(method, pattern string, handlers ...http.Handler) *Route {
+	route := newRoute(&transport.mu, method, pattern)
+	route.handlers = handlers
+	transport.mu.Lock()
+	defer transport.mu.Unlock()
+	transport.routes = append(transport.routes, route)
+	return route
+}
+
+// Fail registers a route which fails matched requests with err
+func (transport *Transport) Fail(method, pattern string, err error) *Route {
+	route := newRoute(&transport.mu, method, pattern)
+	route.err = err
+	transport.mu.Lock()
+	defer transport.mu.Unlock()
+	transport.routes = append(transport.routes, route)
+	return route
+}
+
+// Requests returns the recorded requests
+func (transport *Transport) Requests() []*RecordedRequest {
+	transport.mu.Lock()
+	defer transport.mu.Unlock()
+	return append([]*RecordedRequest(nil), transport.requests...)
+}
+
+// Reset removes all routes and recorded requests
+func (transport *Transport) Reset() {
+	transport.mu.Lock()
+	defer transport.mu.Unlock()
+	transport.routes = nil
+	transport.requests = nil
+}
+
+// RoundTrip serves the request with the first matching route
+func (transport *Transport) RoundTrip(r *http.Request) (*http.Response, error) {
+	var body []byte
+	if r.Body != nil {
+		var err error
+		if body, err = io.ReadAll(r.Body); err != nil {
+			return nil, err
+		}
+		if err := r.Body.Close(); err != nil {
+			return nil, err
+		}
+	}
+	request := r.Clone(r.Context())
+	request.Body = io.NopCloser(bytes.NewReader(body))
+	request.RequestURI = r.URL.RequestURI()
+
+	transport.mu.Lock()
+	transport.requests = append(transport.requests, &RecordedRequest{
+		Method: r.Method,
+		URL:    r.URL,
+		Header: r.Header.Clone(),
+		Body:   body,
+	})
+	var route *Route
+	var handler http.Handler
+	for _, candidate := range transport.routes {
+		if candidate.exhausted() || !candidate.match(request) {
+			continue
+		}
+		route = candidate
+		if len(route.handlers) > 0 {
+			handler = route.handlers[min(route.calls, len(route.handlers)-1)]
+		}
+		route.calls++
+		break
+	}
+	transport.mu.Unlock()
+
+	if route == nil {
+		return nil, fmt.Errorf("%w: %s %s", ErrNoRoute, r.Method, r.URL)
+	}
+	if route.err != nil {
+		return nil, route.err
+	}
+	recorder := httptest.NewRecorder()
+	if handler != nil {
+		handler.ServeHTTP(recorder, request)
+	}
+	result := recorder.Result()
+	result.Request = r
+	return result, nil
+}
+
+// Match adds a matcher, all matchers must accept the request
+func (route *Route) Match(matcher func(*http.Request) bool) *Route {
+	route.mu.Lock()
+	defer route.mu.Unlock()
+	route.matchers = append(route.matchers, matcher)
+	return route
+}
+
+// MatchHeader adds a matcher accepting requests with the header value
+func (route *Route) MatchHeader(key, value string) *Route {
+	return route.Match(func(r *http.Request) bool {
+		return r.Header.Get(key) == value
+	})
+}
+
+// MatchQuery adds a matcher accepting requests with the query parameter value
+func (route *Route) MatchQuery(key, value string) *Route {
+	return route.Match(func(r *http.Request) bool {
+		return r.URL.Query().Get(key) == value
+	})
+}
+
+// Times limits the number of requests served by the route, following requests go to the next matching route
+func (route *Route) Times(n int) *Route {
+	route.mu.Lock()
+	defer route.mu.Unlock()
+	route.times = n
+	return route
+}
+
+// Once limits the route to a single request
+func (route *Route) Once() *Route {
+	return route.Times(1)
+}
+
+// Calls returns the number of requests served by the route
+func (route *Route) Calls() int {
+	route.mu.Lock()
+	defer route.mu.Unlock()
+	return route.calls
+}
+
+func newRoute(mu *sync.Mutex, method, pattern string) *Route {
+	route := &Route{mu: mu, method: strings.ToUpper(method)}
+	if u, err := url.Parse(pattern); err == nil && u.Host != "" {
+		route.host = u.Host
+		pattern = u.Path
+	}
+	route.segments = strings.Split(strings.Trim(pattern, "/"), "/")
+	return route
+}
+
+func (route *Route) exhausted() bool {
+	return route.times > 0 && route.calls >= route.times
+}
+
+func (route *Route) match(r *http.Request) bool {
+	if route.method != "" && route.method != r.Method {
+		return false
+	}
+	if route.host != "" && !strings.EqualFold(route.host, r.URL.Host) {
+		return false
+	}
+	values, ok := matchPath(route.segments, strings.Split(strings.Trim(r.URL.Path, "/"), "/"))
+	if !ok {
+		return false
+	}
+	for _, matcher := range route.matchers {
+		if !matcher(r) {
+			return false
+		}
+	}
+	for name, value := range values {
+		r.SetPathValue(name, value)
+	}
+	return true
+}
+
+// matchPath matches the path segments with the pattern segments and returns the wildcard values
+func matchPath(pattern, segments []string) (map[string]string, bool) {
+	values := make(map[string]string)
+	for i, segment := range pattern {
+		name, wildcard := strings.CutPrefix(segment, "{")
+		name, _ = strings.CutSuffix(name, "}")
+		if rest, ok := strings.CutSuffix(name, "..."); wildcard && ok {
+			values[rest] = strings.Join(segments[min(i, len(segments)):], "/")
+			return values, true
+		}
+		if i >= len(segments) {
+			return nil, false
+		}
+		if wildcard {
+			values[name] = segments[i]
+		} else if segment != segments[i] {
+			return nil, false
+		}
+	}
+	return values, len(segments) == len(pattern)
+}
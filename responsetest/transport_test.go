@@ -0,0 +1,117 @@
+package responsetest
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gopi-frame/response"
+	"github.com/stretchr/testify/assert"
+)
+
+func readBody(t *testing.T, resp *http.Response) string {
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		assert.FailNow(t, err.Error())
+	}
+	return string(body)
+}
+
+func TestTransport(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "report.txt")
+	assert.Nil(t, os.WriteFile(file, []byte("report"), 0o644))
+
+	transport := NewTransport()
+	transport.Handle("GET", "/users/{id}", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		response.New(200).JSON(map[string]string{"id": r.PathValue("id")}).ServeHTTP(w, r)
+	}))
+	transport.Handle("GET", "https://files.example.com/files/{path...}", response.New(200).File(file))
+	transport.Handle("POST", "/users", response.New(201, "created")).MatchHeader("Authorization", "Bearer token")
+	transport.Handle("POST", "/users", response.New(401, "unauthorized"))
+	client := transport.Client()
+
+	resp, err := client.Get("https://api.example.com/users/5")
+	assert.Nil(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.JSONEq(t, `{"id":"5"}`, readBody(t, resp))
+
+	resp, err = client.Get("https://files.example.com/files/2024/report.txt")
+	assert.Nil(t, err)
+	assert.Equal(t, "report", readBody(t, resp))
+
+	_, err = client.Get("https://other.example.com/files/report.txt")
+	assert.True(t, errors.Is(err, ErrNoRoute))
+
+	request, _ := http.NewRequest("POST", "https://api.example.com/users", strings.NewReader(`{"name":"John"}`))
+	request.Header.Set("Authorization", "Bearer token")
+	resp, err = client.Do(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 201, resp.StatusCode)
+	assert.Equal(t, "created", readBody(t, resp))
+
+	resp, err = client.Post("https://api.example.com/users", "application/json", strings.NewReader(`{}`))
+	assert.Nil(t, err)
+	assert.Equal(t, 401, resp.StatusCode)
+	resp.Body.Close()
+
+	requests := transport.Requests()
+	assert.Len(t, requests, 5)
+	assert.Equal(t, "POST", requests[3].Method)
+	assert.Equal(t, "/users", requests[3].URL.Path)
+	assert.Equal(t, "Bearer token", requests[3].Header.Get("Authorization"))
+	assert.Equal(t, `{"name":"John"}`, string(requests[3].Body))
+}
+
+func TestTransport_Sequence(t *testing.T) {
+	transport := NewTransport()
+	route := transport.Handle("GET", "/status", response.New(202, "pending"), response.New(200, "done"))
+	client := transport.Client()
+
+	for _, expected := range []string{"pending", "done", "done"} {
+		resp, err := client.Get("http://api.example.com/status")
+		assert.Nil(t, err)
+		assert.Equal(t, expected, readBody(t, resp))
+	}
+	assert.Equal(t, 3, route.Calls())
+}
+
+func TestTransport_TimesThenFail(t *testing.T) {
+	transport := NewTransport()
+	transport.Handle("GET", "/items", response.New(200, "ok")).Times(2)
+	transport.Fail("GET", "/items", errors.New("connection reset"))
+	client := transport.Client()
+
+	for i := 0; i < 2; i++ {
+		resp, err := client.Get("http://api.example.com/items")
+		assert.Nil(t, err)
+		assert.Equal(t, "ok", readBody(t, resp))
+	}
+	_, err := client.Get("http://api.example.com/items")
+	assert.ErrorContains(t, err, "connection reset")
+
+	transport.Reset()
+	assert.Empty(t, transport.Requests())
+	_, err = client.Get("http://api.example.com/items")
+	assert.True(t, errors.Is(err, ErrNoRoute))
+}
+
+func TestMatchPath(t *testing.T) {
+	split := func(path string) []string { return strings.Split(strings.Trim(path, "/"), "/") }
+	values, ok := matchPath(split("/users/{id}/posts"), split("/users/5/posts"))
+	assert.True(t, ok)
+	assert.Equal(t, map[string]string{"id": "5"}, values)
+	_, ok = matchPath(split("/users/{id}"), split("/users/5/posts"))
+	assert.False(t, ok)
+	_, ok = matchPath(split("/users/{id}/posts"), split("/users/5"))
+	assert.False(t, ok)
+	values, ok = matchPath(split("/files/{path...}"), split("/files/a/b.txt"))
+	assert.True(t, ok)
+	assert.Equal(t, map[string]string{"path": "a/b.txt"}, values)
+	_, ok = matchPath(split("/"), split("/"))
+	assert.True(t, ok)
+}
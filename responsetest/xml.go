@@ -0,0 +1,117 @@
+package responsetest
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// xmlNode is a generic XML element
+type xmlNode struct {
+	name     string
+	attrs    map[string]string
+	children []*xmlNode
+	text     strings.Builder
+}
+
+// AssertXMLPath asserts the text at a dot separated path of the XML body.
+// The first segment names the root element, following segments name child elements,
+// a numeric segment selects among the elements matched by the previous segment
+// and a segment starting with "@" selects an attribute, e.g. "response.items.item.1.@id".
+func (response *TestResponse) AssertXMLPath(path string, expected string) *TestResponse {
+	response.t.Helper()
+	root, err := parseXML(response.body)
+	if err != nil {
+		response.t.Errorf("expected an XML body: %v\nbody: %s", err, truncate(response.body, 1024))
+		return response
+	}
+	actual, err := xmlPath(root, path)
+	if err != nil {
+		response.t.Errorf("XML path %q: %v\nbody: %s", path, err, truncate(response.body, 1024))
+		return response
+	}
+	if actual != expected {
+		response.t.Errorf("XML path %q\nexpected: %q\nactual  : %q", path, expected, actual)
+	}
+	return response
+}
+
+func parseXML(body []byte) (*xmlNode, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(body))
+	var root *xmlNode
+	var stack []*xmlNode
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			node := &xmlNode{name: t.Name.Local, attrs: make(map[string]string)}
+			for _, attr := range t.Attr {
+				node.attrs[attr.Name.Local] = attr.Value
+			}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, node)
+			} else if root == nil {
+				root = node
+			}
+			stack = append(stack, node)
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].text.Write(t)
+			}
+		}
+	}
+	if root == nil {
+		return nil, fmt.Errorf("no root element")
+	}
+	return root, nil
+}
+
+func xmlPath(root *xmlNode, path string) (string, error) {
+	segments := strings.Split(path, ".")
+	if segments[0] != root.name {
+		return "", fmt.Errorf("root element is <%s>", root.name)
+	}
+	matched := []*xmlNode{root}
+	for _, segment := range segments[1:] {
+		if len(matched) == 0 {
+			return "", fmt.Errorf("no element matches before %q", segment)
+		}
+		if strings.HasPrefix(segment, "@") {
+			value, ok := matched[0].attrs[segment[1:]]
+			if !ok {
+				return "", fmt.Errorf("attribute %q not found on <%s>", segment[1:], matched[0].name)
+			}
+			return value, nil
+		}
+		if index, err := strconv.Atoi(segment); err == nil {
+			if index < 0 || index >= len(matched) {
+				return "", fmt.Errorf("index %d out of range of %d elements", index, len(matched))
+			}
+			matched = matched[index : index+1]
+			continue
+		}
+		var children []*xmlNode
+		for _, child := range matched[0].children {
+			if child.name == segment {
+				children = append(children, child)
+			}
+		}
+		if len(children) == 0 {
+			return "", fmt.Errorf("element <%s> not found in <%s>", segment, matched[0].name)
+		}
+		matched = children
+	}
+	return strings.TrimSpace(matched[0].text.String()), nil
+}
@@ -0,0 +1,37 @@
+package responsetest
+
+import (
+	"encoding/xml"
+	"testing"
+
+	"github.com/gopi-frame/response"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTestResponse_AssertXMLPath(t *testing.T) {
+	type item struct {
+		ID   int    `xml:"id,attr"`
+		Name string `xml:"name"`
+	}
+	type document struct {
+		XMLName xml.Name `xml:"response"`
+		Status  string   `xml:"status"`
+		Items   []item   `xml:"items>item"`
+	}
+	resp := response.New(200).XML(document{Status: "ok", Items: []item{{ID: 1, Name: "John"}, {ID: 2, Name: "Jane"}}})
+	Serve(t, resp).
+		AssertXMLPath("response.status", "ok").
+		AssertXMLPath("response.items.item.name", "John").
+		AssertXMLPath("response.items.item.1.name", "Jane").
+		AssertXMLPath("response.items.item.1.@id", "2")
+
+	recorder := &recordingT{TB: t}
+	Serve(recorder, resp).
+		AssertXMLPath("response.status", "failed").
+		AssertXMLPath("document.status", "ok").
+		AssertXMLPath("response.items.item.2.name", "John").
+		AssertXMLPath("response.items.item.@name", "John").
+		AssertXMLPath("response.missing", "")
+	assert.Len(t, recorder.errors, 5)
+	assert.Contains(t, recorder.errors[1], "root element is <response>")
+}
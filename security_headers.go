@@ -0,0 +1,299 @@
+package response
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Frame options
+const (
+	FrameOptionsDeny       = "DENY"
+	FrameOptionsSameOrigin = "SAMEORIGIN"
+)
+
+// Referrer policies
+const (
+	ReferrerPolicyNoReferrer                  = "no-referrer"
+	ReferrerPolicyNoReferrerWhenDowngrade     = "no-referrer-when-downgrade"
+	ReferrerPolicyOrigin                      = "origin"
+	ReferrerPolicyOriginWhenCrossOrigin       = "origin-when-cross-origin"
+	ReferrerPolicySameOrigin                  = "same-origin"
+	ReferrerPolicyStrictOrigin                = "strict-origin"
+	ReferrerPolicyStrictOriginWhenCrossOrigin = "strict-origin-when-cross-origin"
+	ReferrerPolicyUnsafeURL                   = "unsafe-url"
+)
+
+var defaultSecurityHeaders *SecurityHeaders
+
+// SetDefaultSecurityHeaders sets the security headers applied to responses
+// which have no security headers of their own, pass nil to disable it.
+func SetDefaultSecurityHeaders(headers *SecurityHeaders) {
+	defaultSecurityHeaders = headers
+}
+
+// DefaultSecurityHeaders returns the global security headers
+func DefaultSecurityHeaders() *SecurityHeaders {
+	return defaultSecurityHeaders
+}
+
+// SecurityHeaders is a bundle of security related headers added to a response.
+// Empty fields are not sent, and headers set on the response itself take precedence over the bundle.
+type SecurityHeaders struct {
+	ContentSecurityPolicy     *ContentSecurityPolicy
+	StrictTransportSecurity   *StrictTransportSecurity
+	FrameOptions              string
+	ReferrerPolicy            string
+	PermissionsPolicy         map[string][]string
+	CrossOriginOpenerPolicy   string
+	CrossOriginEmbedderPolicy string
+	CrossOriginResourcePolicy string
+	NoSniff                   bool
+}
+
+// BasicSecurityHeaders returns a preset which is safe to enable on any application,
+// it does not send a content security policy.
+func BasicSecurityHeaders() *SecurityHeaders {
+	return &SecurityHeaders{
+		StrictTransportSecurity: &StrictTransportSecurity{MaxAge: 365 * 24 * time.Hour, IncludeSubDomains: true},
+		FrameOptions:            FrameOptionsSameOrigin,
+		ReferrerPolicy:          ReferrerPolicyStrictOriginWhenCrossOrigin,
+		NoSniff:                 true,
+	}
+}
+
+// StrictSecurityHeaders returns a preset which only allows same origin resources
+// and nonce based inline scripts and styles.
+func StrictSecurityHeaders() *SecurityHeaders {
+	return &SecurityHeaders{
+		ContentSecurityPolicy: &ContentSecurityPolicy{
+			Directives: map[string][]string{
+				"default-src":     {"'self'"},
+				"base-uri":        {"'self'"},
+				"form-action":     {"'self'"},
+				"object-src":      {"'none'"},
+				"script-src":      {"'self'"},
+				"style-src":       {"'self'"},
+				"frame-ancestors": {"'none'"},
+			},
+			NonceDirectives: []string{"script-src", "style-src"},
+		},
+		StrictTransportSecurity:   &StrictTransportSecurity{MaxAge: 2 * 365 * 24 * time.Hour, IncludeSubDomains: true, Preload: true},
+		FrameOptions:              FrameOptionsDeny,
+		ReferrerPolicy:            ReferrerPolicyNoReferrer,
+		PermissionsPolicy:         map[string][]string{"camera": {}, "geolocation": {}, "microphone": {}},
+		CrossOriginOpenerPolicy:   "same-origin",
+		CrossOriginEmbedderPolicy: "require-corp",
+		CrossOriginResourcePolicy: "same-origin",
+		NoSniff:                   true,
+	}
+}
+
+// securityHeaderNames are the headers set by [SecurityHeaders]
+var securityHeaderNames = map[string]bool{
+	"Content-Security-Policy":             true,
+	"Content-Security-Policy-Report-Only": true,
+	"Strict-Transport-Security":           true,
+	"X-Frame-Options":                     true,
+	"Referrer-Policy":                     true,
+	"Permissions-Policy":                  true,
+	"Cross-Origin-Opener-Policy":          true,
+	"Cross-Origin-Embedder-Policy":        true,
+	"Cross-Origin-Resource-Policy":        true,
+	"X-Content-Type-Options":              true,
+}
+
+// Apply adds the security headers to header, headers which already exist are kept.
+// The nonce is added to the nonce directives of the content security policy.
+func (securityHeaders *SecurityHeaders) Apply(header http.Header, nonce string) {
+	set := func(key, value string) {
+		if value != "" && header.Get(key) == "" {
+			header.Set(key, value)
+		}
+	}
+	if csp := securityHeaders.ContentSecurityPolicy; csp != nil {
+		policy := csp
+		if securityHeaders.FrameOptions != "" {
+			policy = csp.withFrameAncestors(securityHeaders.FrameOptions)
+		}
+		set(csp.HeaderName(), policy.Build(nonce))
+	}
+	if hsts := securityHeaders.StrictTransportSecurity; hsts != nil {
+		set("Strict-Transport-Security", hsts.String())
+	}
+	set("X-Frame-Options", securityHeaders.FrameOptions)
+	set("Referrer-Policy", securityHeaders.ReferrerPolicy)
+	set("Permissions-Policy", buildPermissionsPolicy(securityHeaders.PermissionsPolicy))
+	set("Cross-Origin-Opener-Policy", securityHeaders.CrossOriginOpenerPolicy)
+	set("Cross-Origin-Embedder-Policy", securityHeaders.CrossOriginEmbedderPolicy)
+	set("Cross-Origin-Resource-Policy", securityHeaders.CrossOriginResourcePolicy)
+	if securityHeaders.NoSniff {
+		set("X-Content-Type-Options", "nosniff")
+	}
+}
+
+// ContentSecurityPolicy is used to build the Content-Security-Policy header.
+//   - Directives: The directive sources, e.g. "script-src": {"'self'", "https://cdn.example.com"}.
+//   - NonceDirectives: The directives which allow the per-request nonce, see [CSPNonce].
+//   - ReportOnly: When true, the policy is sent as Content-Security-Policy-Report-Only.
+//   - ReportURI: The endpoint which receives violation reports.
+type ContentSecurityPolicy struct {
+	Directives      map[string][]string
+	NonceDirectives []string
+	ReportOnly      bool
+	ReportURI       string
+}
+
+// HeaderName returns the name of the header the policy is sent with
+func (csp *ContentSecurityPolicy) HeaderName() string {
+	if csp.ReportOnly {
+		return "Content-Security-Policy-Report-Only"
+	}
+	return "Content-Security-Policy"
+}
+
+// UsesNonce returns if the policy contains nonce directives
+func (csp *ContentSecurityPolicy) UsesNonce() bool {
+	return len(csp.NonceDirectives) > 0
+}
+
+// Build returns the header value, directives are sorted by name
+func (csp *ContentSecurityPolicy) Build(nonce string) string {
+	directives := make(map[string][]string, len(csp.Directives)+len(csp.NonceDirectives)+1)
+	for name, sources := range csp.Directives {
+		directives[name] = slices.Clone(sources)
+	}
+	if nonce != "" {
+		for _, name := range csp.NonceDirectives {
+			directives[name] = append(directives[name], "'nonce-"+nonce+"'")
+		}
+	}
+	if csp.ReportURI != "" {
+		directives["report-uri"] = []string{csp.ReportURI}
+	}
+	names := make([]string, 0, len(directives))
+	for name := range directives {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		parts = append(parts, strings.TrimSpace(name+" "+strings.Join(directives[name], " ")))
+	}
+	return strings.Join(parts, "; ")
+}
+
+func (csp *ContentSecurityPolicy) withFrameAncestors(frameOptions string) *ContentSecurityPolicy {
+	if _, ok := csp.Directives["frame-ancestors"]; ok {
+		return csp
+	}
+	var source string
+	switch strings.ToUpper(frameOptions) {
+	case FrameOptionsDeny:
+		source = "'none'"
+	case FrameOptionsSameOrigin:
+		source = "'self'"
+	default:
+		return csp
+	}
+	clone := *csp
+	clone.Directives = make(map[string][]string, len(csp.Directives)+1)
+	for name, sources := range csp.Directives {
+		clone.Directives[name] = sources
+	}
+	clone.Directives["frame-ancestors"] = []string{source}
+	return &clone
+}
+
+// StrictTransportSecurity is used to build the Strict-Transport-Security header
+type StrictTransportSecurity struct {
+	MaxAge            time.Duration
+	IncludeSubDomains bool
+	Preload           bool
+}
+
+// String returns the header value
+func (hsts *StrictTransportSecurity) String() string {
+	value := fmt.Sprintf("max-age=%d", int64(hsts.MaxAge/time.Second))
+	if hsts.IncludeSubDomains {
+		value += "; includeSubDomains"
+	}
+	if hsts.Preload {
+		value += "; preload"
+	}
+	return value
+}
+
+func buildPermissionsPolicy(policy map[string][]string) string {
+	if len(policy) == 0 {
+		return ""
+	}
+	features := make([]string, 0, len(policy))
+	for feature := range policy {
+		features = append(features, feature)
+	}
+	sort.Strings(features)
+	parts := make([]string, 0, len(features))
+	for _, feature := range features {
+		allowlist := make([]string, 0, len(policy[feature]))
+		for _, origin := range policy[feature] {
+			if origin == "self" || origin == "*" {
+				allowlist = append(allowlist, origin)
+			} else {
+				allowlist = append(allowlist, `"`+origin+`"`)
+			}
+		}
+		if len(allowlist) == 1 && allowlist[0] == "*" {
+			parts = append(parts, feature+"=*")
+			continue
+		}
+		parts = append(parts, feature+"=("+strings.Join(allowlist, " ")+")")
+	}
+	return strings.Join(parts, ", ")
+}
+
+// cspNonceKey is the request context key of the content security policy nonce
+type cspNonceKey struct{}
+
+// cspNonce is the nonce of a request, generated on first use
+type cspNonce struct {
+	once  sync.Once
+	value string
+}
+
+// CSPNonce returns the content security policy nonce of the request being served,
+// the same nonce is used in the header and the body of the response.
+// An empty string is returned for a request which is not served by a response.
+func CSPNonce(r *http.Request) string {
+	nonce, ok := r.Context().Value(cspNonceKey{}).(*cspNonce)
+	if !ok {
+		return ""
+	}
+	nonce.once.Do(func() {
+		nonce.value = generateNonce()
+	})
+	return nonce.value
+}
+
+// withCSPNonce returns the request with a nonce in its context, a request which already has one is returned as it is
+func withCSPNonce(r *http.Request) *http.Request {
+	if _, ok := r.Context().Value(cspNonceKey{}).(*cspNonce); ok {
+		return r
+	}
+	return r.WithContext(context.WithValue(r.Context(), cspNonceKey{}, new(cspNonce)))
+}
+
+func generateNonce() string {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf)
+}
@@ -0,0 +1,102 @@
+package response
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContentSecurityPolicy_Build(t *testing.T) {
+	csp := &ContentSecurityPolicy{
+		Directives: map[string][]string{
+			"script-src":                {"'self'"},
+			"default-src":               {"'self'"},
+			"upgrade-insecure-requests": nil,
+		},
+		NonceDirectives: []string{"script-src"},
+		ReportURI:       "/csp-report",
+	}
+	assert.Equal(t, "Content-Security-Policy", csp.HeaderName())
+	assert.Equal(t,
+		"default-src 'self'; report-uri /csp-report; script-src 'self' 'nonce-abc'; upgrade-insecure-requests",
+		csp.Build("abc"),
+	)
+	assert.Equal(t, []string{"'self'"}, csp.Directives["script-src"])
+	csp.ReportOnly = true
+	assert.Equal(t, "Content-Security-Policy-Report-Only", csp.HeaderName())
+}
+
+func TestStrictTransportSecurity_String(t *testing.T) {
+	hsts := &StrictTransportSecurity{MaxAge: time.Hour, IncludeSubDomains: true, Preload: true}
+	assert.Equal(t, "max-age=3600; includeSubDomains; preload", hsts.String())
+}
+
+func TestSecurityHeaders_Apply(t *testing.T) {
+	security := &SecurityHeaders{
+		ContentSecurityPolicy: &ContentSecurityPolicy{Directives: map[string][]string{"default-src": {"'self'"}}},
+		FrameOptions:          FrameOptionsDeny,
+		ReferrerPolicy:        ReferrerPolicyNoReferrer,
+		PermissionsPolicy: map[string][]string{
+			"camera":      {},
+			"fullscreen":  {"*"},
+			"geolocation": {"self", "https://maps.example.com"},
+		},
+		CrossOriginOpenerPolicy: "same-origin",
+		NoSniff:                 true,
+	}
+	recorder := httptest.NewRecorder()
+	recorder.Header().Set("Referrer-Policy", "origin")
+	security.Apply(recorder.Header(), "")
+
+	header := recorder.Header()
+	assert.Equal(t, "default-src 'self'; frame-ancestors 'none'", header.Get("Content-Security-Policy"))
+	assert.Equal(t, "DENY", header.Get("X-Frame-Options"))
+	assert.Equal(t, "origin", header.Get("Referrer-Policy"))
+	assert.Equal(t, `camera=(), fullscreen=*, geolocation=(self "https://maps.example.com")`, header.Get("Permissions-Policy"))
+	assert.Equal(t, "same-origin", header.Get("Cross-Origin-Opener-Policy"))
+	assert.Equal(t, "", header.Get("Cross-Origin-Embedder-Policy"))
+	assert.Equal(t, "nosniff", header.Get("X-Content-Type-Options"))
+	assert.Nil(t, security.ContentSecurityPolicy.Directives["frame-ancestors"])
+}
+
+func TestResponse_SecurityHeaders(t *testing.T) {
+	SetDefaultSecurityHeaders(BasicSecurityHeaders())
+	defer SetDefaultSecurityHeaders(nil)
+
+	response := New(200, "Hello, World!")
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest("GET", "/", nil)
+	response.ServeHTTP(recorder, request)
+
+	result := recorder.Result()
+	assert.Equal(t, "SAMEORIGIN", result.Header.Get("X-Frame-Options"))
+	assert.Equal(t, "nosniff", result.Header.Get("X-Content-Type-Options"))
+	assert.Equal(t, "max-age=31536000; includeSubDomains", result.Header.Get("Strict-Transport-Security"))
+}
+
+func TestHtmlResponse_CSPNonce(t *testing.T) {
+	response := &HtmlResponse{Response: New(200)}
+	response.SetHTML(`<script nonce="{{cspNonce}}"></script>`)
+	response.SetSecurityHeaders(StrictSecurityHeaders())
+
+	serve := func() (string, string) {
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest("GET", "/", nil)
+		response.ServeHTTP(recorder, request)
+		body := recorder.Body.String()
+		nonce := strings.TrimSuffix(strings.TrimPrefix(body, `<script nonce="`), `"></script>`)
+		return nonce, recorder.Header().Get("Content-Security-Policy")
+	}
+	nonce, csp := serve()
+	assert.NotEmpty(t, nonce)
+	assert.True(t, strings.Contains(csp, "script-src 'self' 'nonce-"+nonce+"'"))
+	assert.True(t, strings.Contains(csp, "style-src 'self' 'nonce-"+nonce+"'"))
+
+	next, csp := serve()
+	assert.NotEqual(t, nonce, next)
+	assert.True(t, strings.Contains(csp, "'nonce-"+next+"'"))
+	assert.Empty(t, CSPNonce(httptest.NewRequest("GET", "/", nil)))
+}
@@ -0,0 +1,92 @@
+package response
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Created returns a 201 response whose Location header points to the created resource
+func Created(location string, body ...any) *Response {
+	response := New(http.StatusCreated, body...)
+	if location != "" {
+		response.SetHeader("Location", location)
+	}
+	return response
+}
+
+// Accepted returns a 202 response whose Location header points to the status monitor of the request
+func Accepted(statusURL string) *Response {
+	response := New(http.StatusAccepted)
+	if statusURL != "" {
+		response.SetHeader("Location", statusURL)
+	}
+	return response
+}
+
+// NoContent returns a 204 response, which is sent without a body
+func NoContent() *Response {
+	return New(http.StatusNoContent)
+}
+
+// NotModified returns a 304 response, which is sent without a body
+func NotModified() *Response {
+	return New(http.StatusNotModified)
+}
+
+// NotFound returns a 404 response
+func NotFound() *Response {
+	return New(http.StatusNotFound, http.StatusText(http.StatusNotFound))
+}
+
+// MethodNotAllowed returns a 405 response whose Allow header lists the allowed methods
+func MethodNotAllowed(allowed ...string) *Response {
+	response := New(http.StatusMethodNotAllowed, http.StatusText(http.StatusMethodNotAllowed))
+	methods := make([]string, 0, len(allowed))
+	for _, method := range allowed {
+		methods = append(methods, strings.ToUpper(method))
+	}
+	response.SetHeader("Allow", strings.Join(methods, ", "))
+	return response
+}
+
+// Gone returns a 410 response
+func Gone() *Response {
+	return New(http.StatusGone, http.StatusText(http.StatusGone))
+}
+
+// TooManyRequests returns a 429 response, the Retry-After header is set when retryAfter is positive
+func TooManyRequests(retryAfter time.Duration) *Response {
+	response := New(http.StatusTooManyRequests, http.StatusText(http.StatusTooManyRequests))
+	response.SetRetryAfter(retryAfter)
+	return response
+}
+
+// ServiceUnavailable returns a 503 response, the Retry-After header is set when retryAfter is positive
+func ServiceUnavailable(retryAfter time.Duration) *Response {
+	response := New(http.StatusServiceUnavailable, http.StatusText(http.StatusServiceUnavailable))
+	response.SetRetryAfter(retryAfter)
+	return response
+}
+
+// SetRetryAfter sets the Retry-After header in seconds, rounded up, a duration which is not positive removes it
+func (response *Response) SetRetryAfter(retryAfter time.Duration) {
+	if retryAfter <= 0 {
+		response.headers.Del("Retry-After")
+		return
+	}
+	seconds := int64((retryAfter + time.Second - 1) / time.Second)
+	response.SetHeader("Retry-After", strconv.FormatInt(seconds, 10))
+}
+
+// bodyAllowedForStatus returns if a response with the status can have a body, 1xx, 204 and 304 responses can not
+func bodyAllowedForStatus(statusCode int) bool {
+	switch {
+	case statusCode >= 100 && statusCode <= 199:
+		return false
+	case statusCode == http.StatusNoContent, statusCode == http.StatusNotModified:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,80 @@
+package response
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatusConstructors(t *testing.T) {
+	cases := []struct {
+		name     string
+		response http.Handler
+		status   int
+		header   string
+		value    string
+		body     string
+	}{
+		{"created", Created("/users/1", "created"), http.StatusCreated, "Location", "/users/1", "created"},
+		{"created json", Created("/users/1").JSON(map[string]int{"id": 1}), http.StatusCreated, "Location", "/users/1", `{"id":1}`},
+		{"accepted", Accepted("/jobs/7"), http.StatusAccepted, "Location", "/jobs/7", ""},
+		{"no content", NoContent(), http.StatusNoContent, "", "", ""},
+		{"not modified", NotModified(), http.StatusNotModified, "", "", ""},
+		{"not found", NotFound(), http.StatusNotFound, "", "", "Not Found"},
+		{"method not allowed", MethodNotAllowed("get", "HEAD"), http.StatusMethodNotAllowed, "Allow", "GET, HEAD", "Method Not Allowed"},
+		{"gone", Gone(), http.StatusGone, "", "", "Gone"},
+		{"too many requests", TooManyRequests(1500 * time.Millisecond), http.StatusTooManyRequests, "Retry-After", "2", "Too Many Requests"},
+		{"service unavailable", ServiceUnavailable(time.Minute), http.StatusServiceUnavailable, "Retry-After", "60", "Service Unavailable"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			c.response.ServeHTTP(recorder, httptest.NewRequest("GET", "/", nil))
+			assert.Equal(t, c.status, recorder.Code)
+			if c.header != "" {
+				assert.Equal(t, c.value, recorder.Header().Get(c.header))
+			}
+			assert.Equal(t, c.body, recorder.Body.String())
+		})
+	}
+}
+
+func TestResponse_SetRetryAfter(t *testing.T) {
+	response := ServiceUnavailable(0)
+	assert.False(t, response.HasHeader("Retry-After"))
+	response.SetRetryAfter(time.Second)
+	assert.Equal(t, "1", response.Header("Retry-After"))
+	response.SetRetryAfter(-time.Second)
+	assert.False(t, response.HasHeader("Retry-After"))
+}
+
+func TestBodyNotAllowed(t *testing.T) {
+	responses := map[string]http.Handler{
+		"content": New(http.StatusNoContent, "ignored"),
+		"json":    NotModified().JSON(map[string]string{"ignored": "yes"}),
+		"reader":  New(http.StatusNoContent).Reader(strings.NewReader("ignored")),
+		"stream": New(http.StatusNotModified).Stream(func(w io.Writer) bool {
+			_, _ = w.Write([]byte("ignored"))
+			return false
+		}),
+	}
+	for name, response := range responses {
+		t.Run(name, func(t *testing.T) {
+			server := httptest.NewServer(response)
+			defer server.Close()
+			resp, err := http.Get(server.URL)
+			if !assert.Nil(t, err) {
+				return
+			}
+			defer resp.Body.Close()
+			body, err := io.ReadAll(resp.Body)
+			assert.Nil(t, err)
+			assert.Empty(t, body)
+		})
+	}
+}
@@ -19,12 +19,22 @@ func (streamed *StreamedResponse) SetStep(step func(w io.Writer) bool) *Streamed
 
 // ServeHTTP sends the response
 func (streamed *StreamedResponse) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	streamed.send(w, r, streamed.serve)
+}
+
+func (streamed *StreamedResponse) serve(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	select {
 	case <-ctx.Done():
 	default:
+		streamed.sendHeaders(w, r)
+		// an implicit 200 lets the first write detect the content type
+		if streamed.statusCode != http.StatusOK {
+			w.WriteHeader(streamed.statusCode)
+		}
 		for {
-			if streamed.step == nil {
+			// the status may not allow a body
+			if streamed.step == nil || !bodyAllowedForStatus(streamed.statusCode) {
 				break
 			}
 			if !streamed.step(w) {
@@ -0,0 +1,340 @@
+package response
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// SymlinkPolicy decides how symbolic links found in directories are archived
+type SymlinkPolicy int
+
+const (
+	// SymlinkSkip leaves symbolic links out of the archive
+	SymlinkSkip SymlinkPolicy = iota
+	// SymlinkPreserve archives symbolic links as links, links found in an [fs.FS] are skipped
+	SymlinkPreserve
+	// SymlinkFollow archives the files and directories symbolic links point to,
+	// links to directories found in an [fs.FS] are skipped
+	SymlinkFollow
+)
+
+// TarResponse is used to send a tar or gzip-compressed tar archive, it is streamed one entry per step.
+// Entries can be added from directories (AddDir), file systems (AddFS), files (AddFile), readers (AddReader) and byte slices (AddBytes).
+// Directories are walked when the response is sent, keeping file modes and modification times,
+// the symbolic link policy (SetSymlinkPolicy) and the filter (SetFilter) apply to the walked entries.
+// Entries of a reader with unknown length are buffered in memory as tar headers need the size upfront.
+type TarResponse struct {
+	*StreamedResponse
+	filename string
+	gzip     bool
+	symlinks SymlinkPolicy
+	filter   func(name string, info fs.FileInfo) bool
+	sources  []func() ([]archiveEntry, error)
+}
+
+// SetFilename sets the download filename
+func (tarResponse *TarResponse) SetFilename(filename string) *TarResponse {
+	tarResponse.filename = filename
+	return tarResponse
+}
+
+// SetGzip sets if the archive is gzip-compressed
+func (tarResponse *TarResponse) SetGzip(compressed bool) *TarResponse {
+	tarResponse.gzip = compressed
+	return tarResponse
+}
+
+// SetSymlinkPolicy sets how symbolic links found in directories are archived, links are skipped by default
+func (tarResponse *TarResponse) SetSymlinkPolicy(policy SymlinkPolicy) *TarResponse {
+	tarResponse.symlinks = policy
+	return tarResponse
+}
+
+// SetFilter sets the filter of walked entries, name is the entry name in the archive,
+// a rejected directory is skipped with all its content
+func (tarResponse *TarResponse) SetFilter(filter func(name string, info fs.FileInfo) bool) *TarResponse {
+	tarResponse.filter = filter
+	return tarResponse
+}
+
+// AddDir adds the directory and all its content, entries are named by their path relative to dir joined to prefix
+func (tarResponse *TarResponse) AddDir(dir, prefix string) *TarResponse {
+	tarResponse.sources = append(tarResponse.sources, func() ([]archiveEntry, error) {
+		return tarResponse.walkDir(dir, prefix)
+	})
+	return tarResponse
+}
+
+// AddFS adds all entries under root of fsys, entries are named by their path relative to root joined to prefix
+func (tarResponse *TarResponse) AddFS(fsys fs.FS, root, prefix string) *TarResponse {
+	tarResponse.sources = append(tarResponse.sources, func() ([]archiveEntry, error) {
+		return tarResponse.walkFS(fsys, root, prefix)
+	})
+	return tarResponse
+}
+
+// AddFile adds a file from disk, the entry is named after the base name of the file unless name is given
+func (tarResponse *TarResponse) AddFile(file string, name ...string) *TarResponse {
+	return tarResponse.addEntry(newFileArchiveEntry(file, name...))
+}
+
+// AddReader adds an entry read from reader, the reader is closed after use if it is an [io.Closer]
+func (tarResponse *TarResponse) AddReader(name string, reader io.Reader, modTime time.Time) *TarResponse {
+	return tarResponse.addEntry(newReaderArchiveEntry(name, reader, modTime))
+}
+
+// AddBytes adds an entry with the given content
+func (tarResponse *TarResponse) AddBytes(name string, content []byte, modTime time.Time) *TarResponse {
+	return tarResponse.AddReader(name, bytes.NewReader(content), modTime)
+}
+
+func (tarResponse *TarResponse) addEntry(entry archiveEntry) *TarResponse {
+	tarResponse.sources = append(tarResponse.sources, func() ([]archiveEntry, error) {
+		return []archiveEntry{entry}, nil
+	})
+	return tarResponse
+}
+
+// ServeHTTP writes the archive
+func (tarResponse *TarResponse) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	tarResponse.send(w, r, tarResponse.serve)
+}
+
+func (tarResponse *TarResponse) serve(w http.ResponseWriter, r *http.Request) {
+	// the status may not allow a body, the sources are not walked
+	if !bodyAllowedForStatus(tarResponse.statusCode) {
+		tarResponse.sendHeaders(w, r)
+		w.WriteHeader(tarResponse.statusCode)
+		return
+	}
+	var entries []archiveEntry
+	for _, source := range tarResponse.sources {
+		sourceEntries, err := source()
+		if err != nil {
+			panic(err)
+		}
+		entries = append(entries, sourceEntries...)
+	}
+	if tarResponse.gzip {
+		tarResponse.SetHeader("Content-Type", "application/gzip")
+		setContentDisposition(tarResponse.headers, tarResponse.filename, "archive.tar.gz")
+	} else {
+		tarResponse.SetHeader("Content-Type", "application/x-tar")
+		setContentDisposition(tarResponse.headers, tarResponse.filename, "archive.tar")
+	}
+
+	ctx := r.Context()
+	var tw *tar.Writer
+	var gw *gzip.Writer
+	tarResponse.SetStep(func(w io.Writer) bool {
+		if tw == nil {
+			var out io.Writer = &contextWriter{ctx: ctx, w: w}
+			if tarResponse.gzip {
+				gw = gzip.NewWriter(out)
+				out = gw
+			}
+			tw = tar.NewWriter(out)
+		}
+		if len(entries) == 0 {
+			abortOnError(ctx, tw.Close())
+			if gw != nil {
+				abortOnError(ctx, gw.Close())
+			}
+			return false
+		}
+		abortOnError(ctx, writeTarEntry(tw, entries[0]))
+		entries = entries[1:]
+		return true
+	})
+	tarResponse.StreamedResponse.serve(w, r)
+}
+
+func writeTarEntry(tw *tar.Writer, entry archiveEntry) error {
+	info := entry.info
+	var reader io.Reader
+	if entry.open != nil {
+		rc, openInfo, err := entry.open()
+		if err != nil {
+			return err
+		}
+		defer rc.Close()
+		if openInfo != nil {
+			info = openInfo
+		}
+		reader = rc
+	}
+	var header *tar.Header
+	if info != nil {
+		var err error
+		if header, err = tar.FileInfoHeader(info, entry.link); err != nil {
+			return err
+		}
+	} else {
+		header = &tar.Header{Typeflag: tar.TypeReg, Size: entry.size}
+		if header.Size < 0 {
+			content, err := io.ReadAll(reader)
+			if err != nil {
+				return err
+			}
+			header.Size = int64(len(content))
+			reader = bytes.NewReader(content)
+		}
+	}
+	header.Name = entry.name
+	if header.Typeflag == tar.TypeDir {
+		header.Name += "/"
+	}
+	if !entry.modTime.IsZero() {
+		header.ModTime = entry.modTime
+	}
+	if header.ModTime.IsZero() {
+		header.ModTime = time.Now()
+	}
+	if entry.mode != 0 && info == nil {
+		header.Mode = int64(entry.mode.Perm())
+	}
+	if err := tw.WriteHeader(header); err != nil {
+		return err
+	}
+	if header.Typeflag != tar.TypeReg || reader == nil {
+		return nil
+	}
+	_, err := io.CopyN(tw, reader, header.Size)
+	return err
+}
+
+func (tarResponse *TarResponse) accept(name string, info fs.FileInfo) bool {
+	return tarResponse.filter == nil || tarResponse.filter(name, info)
+}
+
+// walkDir returns the entries of a directory on disk, directories linked more than once are archived once
+func (tarResponse *TarResponse) walkDir(root, prefix string) ([]archiveEntry, error) {
+	var entries []archiveEntry
+	visited := make(map[string]bool)
+	var walk func(dir, prefix string) error
+	walk = func(dir, prefix string) error {
+		if real, err := filepath.EvalSymlinks(dir); err == nil {
+			if visited[real] {
+				return nil
+			}
+			visited[real] = true
+		}
+		return filepath.WalkDir(dir, func(file string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			rel, err := filepath.Rel(dir, file)
+			if err != nil {
+				return err
+			}
+			if rel == "." && !d.IsDir() {
+				rel = filepath.Base(file)
+			}
+			name := archiveEntryName(path.Join(prefix, filepath.ToSlash(rel)))
+			if name == "" {
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			entry := archiveEntry{name: name, info: info}
+			if d.Type()&fs.ModeSymlink != 0 {
+				switch tarResponse.symlinks {
+				case SymlinkPreserve:
+					if entry.link, err = os.Readlink(file); err != nil {
+						return err
+					}
+				case SymlinkFollow:
+					target, err := os.Stat(file)
+					if err != nil {
+						return nil
+					}
+					if target.IsDir() {
+						real, err := filepath.EvalSymlinks(file)
+						if err != nil || !tarResponse.accept(name, target) {
+							return nil
+						}
+						return walk(real, name)
+					}
+					entry.info = target
+				default:
+					return nil
+				}
+			}
+			if !tarResponse.accept(name, entry.info) {
+				if d.IsDir() {
+					return fs.SkipDir
+				}
+				return nil
+			}
+			if entry.info.Mode().IsRegular() {
+				entry.open = func() (io.ReadCloser, fs.FileInfo, error) {
+					f, err := os.Open(file)
+					return f, nil, err
+				}
+			}
+			entries = append(entries, entry)
+			return nil
+		})
+	}
+	return entries, walk(root, prefix)
+}
+
+// walkFS returns the entries of a file system
+func (tarResponse *TarResponse) walkFS(fsys fs.FS, root, prefix string) ([]archiveEntry, error) {
+	var entries []archiveEntry
+	err := fs.WalkDir(fsys, root, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel := strings.TrimPrefix(strings.TrimPrefix(name, root), "/")
+		if root == "." {
+			rel = name
+		}
+		if rel == "" && !d.IsDir() {
+			rel = path.Base(name)
+		}
+		entryName := archiveEntryName(path.Join(prefix, rel))
+		if entryName == "" {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if d.Type()&fs.ModeSymlink != 0 {
+			if tarResponse.symlinks != SymlinkFollow {
+				return nil
+			}
+			if info, err = fs.Stat(fsys, name); err != nil || info.IsDir() {
+				return nil
+			}
+		}
+		if !tarResponse.accept(entryName, info) {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		entry := archiveEntry{name: entryName, info: info}
+		if info.Mode().IsRegular() {
+			entry.open = func() (io.ReadCloser, fs.FileInfo, error) {
+				f, err := fsys.Open(name)
+				return f, nil, err
+			}
+		}
+		entries = append(entries, entry)
+		return nil
+	})
+	return entries, err
+}
@@ -0,0 +1,154 @@
+package response
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type tarTestEntry struct {
+	header  *tar.Header
+	content string
+}
+
+func readTar(t *testing.T, reader io.Reader) map[string]tarTestEntry {
+	tr := tar.NewReader(reader)
+	entries := make(map[string]tarTestEntry)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			assert.FailNow(t, err.Error())
+		}
+		content, err := io.ReadAll(tr)
+		if err != nil {
+			assert.FailNow(t, err.Error())
+		}
+		entries[header.Name] = tarTestEntry{header: header, content: string(content)}
+	}
+	return entries
+}
+
+func newTarTestDir(t *testing.T) string {
+	dir := t.TempDir()
+	assert.Nil(t, os.MkdirAll(filepath.Join(dir, "logs", "old"), 0o755))
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "logs", "app.log"), []byte("app"), 0o640))
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "logs", "old", "app.log.1"), []byte("old"), 0o600))
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "run.sh"), []byte("#!/bin/sh"), 0o755))
+	assert.Nil(t, os.Symlink("run.sh", filepath.Join(dir, "start.sh")))
+	assert.Nil(t, os.Symlink("logs", filepath.Join(dir, "current")))
+	return dir
+}
+
+func TestTarResponse(t *testing.T) {
+	dir := newTarTestDir(t)
+	modTime := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	response := New(200).Tar("bundle.tar")
+	response.AddDir(dir, "bundle").
+		AddBytes("notes.txt", []byte("hello"), modTime).
+		AddReader("stream.txt", io.LimitReader(strings.NewReader("streamed content"), 8), modTime).
+		AddFS(fstest.MapFS{"config/app.yaml": {Data: []byte("debug: true"), Mode: 0o600, ModTime: modTime}}, "config", "config")
+
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest("GET", "/", nil)
+	response.ServeHTTP(recorder, request)
+
+	result := recorder.Result()
+	assert.Equal(t, 200, result.StatusCode)
+	assert.Equal(t, "application/x-tar", result.Header.Get("Content-Type"))
+	assert.Equal(t, "attachment; filename=bundle.tar", result.Header.Get("Content-Disposition"))
+
+	entries := readTar(t, recorder.Body)
+	assert.Equal(t, byte(tar.TypeDir), entries["bundle/logs/"].header.Typeflag)
+	assert.Equal(t, "app", entries["bundle/logs/app.log"].content)
+	assert.Equal(t, int64(0o640), entries["bundle/logs/app.log"].header.Mode)
+	assert.Equal(t, "old", entries["bundle/logs/old/app.log.1"].content)
+	assert.Equal(t, int64(0o755), entries["bundle/run.sh"].header.Mode)
+	assert.NotContains(t, entries, "bundle/start.sh")
+	assert.NotContains(t, entries, "bundle/current/")
+	assert.Equal(t, "hello", entries["notes.txt"].content)
+	assert.True(t, modTime.Equal(entries["notes.txt"].header.ModTime))
+	assert.Equal(t, "streamed", entries["stream.txt"].content)
+	assert.Equal(t, "debug: true", entries["config/app.yaml"].content)
+	assert.Equal(t, int64(0o600), entries["config/app.yaml"].header.Mode)
+}
+
+func TestTarResponse_NoContent(t *testing.T) {
+	result, body := serveOverHTTP(t, New(http.StatusNoContent).Tar("empty.tar").AddDir(t.TempDir(), ""))
+	assert.Equal(t, http.StatusNoContent, result.StatusCode)
+	assert.Empty(t, body)
+}
+
+func TestTarResponse_Symlinks(t *testing.T) {
+	dir := newTarTestDir(t)
+
+	t.Run("Preserve", func(t *testing.T) {
+		response := New(200).Tar("")
+		response.SetSymlinkPolicy(SymlinkPreserve).AddDir(dir, "")
+		recorder := httptest.NewRecorder()
+		response.ServeHTTP(recorder, httptest.NewRequest("GET", "/", nil))
+
+		entries := readTar(t, recorder.Body)
+		assert.Equal(t, byte(tar.TypeSymlink), entries["start.sh"].header.Typeflag)
+		assert.Equal(t, "run.sh", entries["start.sh"].header.Linkname)
+		assert.Equal(t, "logs", entries["current"].header.Linkname)
+	})
+
+	t.Run("Follow", func(t *testing.T) {
+		response := New(200).Tar("")
+		response.SetSymlinkPolicy(SymlinkFollow).AddDir(dir, "")
+		recorder := httptest.NewRecorder()
+		response.ServeHTTP(recorder, httptest.NewRequest("GET", "/", nil))
+
+		entries := readTar(t, recorder.Body)
+		assert.Equal(t, "#!/bin/sh", entries["start.sh"].content)
+		assert.Equal(t, byte(tar.TypeReg), entries["start.sh"].header.Typeflag)
+		assert.Equal(t, "app", entries["current/app.log"].content)
+	})
+}
+
+func TestTarResponse_Filter(t *testing.T) {
+	dir := newTarTestDir(t)
+	response := New(200).TarGz("logs.tar.gz")
+	response.SetFilter(func(name string, info fs.FileInfo) bool {
+		return name != "logs/old" && !strings.HasSuffix(name, ".sh")
+	}).AddDir(dir, "")
+
+	recorder := httptest.NewRecorder()
+	response.ServeHTTP(recorder, httptest.NewRequest("GET", "/", nil))
+
+	assert.Equal(t, "application/gzip", recorder.Header().Get("Content-Type"))
+	assert.Equal(t, "attachment; filename=logs.tar.gz", recorder.Header().Get("Content-Disposition"))
+	gr, err := gzip.NewReader(bytes.NewReader(recorder.Body.Bytes()))
+	if err != nil {
+		assert.FailNow(t, err.Error())
+	}
+	entries := readTar(t, gr)
+	assert.Contains(t, entries, "logs/app.log")
+	assert.NotContains(t, entries, "logs/old/")
+	assert.NotContains(t, entries, "logs/old/app.log.1")
+	assert.NotContains(t, entries, "run.sh")
+}
+
+func TestTarResponse_DirNotExists(t *testing.T) {
+	response := New(200).Tar("")
+	response.AddDir("not-exists", "")
+	assert.Panics(t, func() {
+		response.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	})
+}
@@ -0,0 +1,396 @@
+package response
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ValidationFlashCookie is the name of the cookie the errors are flashed in when a form is redirected back
+const ValidationFlashCookie = "validation_errors"
+
+// defaultValidationMessages are the English message templates of common rules
+var defaultValidationMessages = map[string]string{
+	"required": "The {field} field is required.",
+	"email":    "The {field} field must be a valid email address.",
+	"min":      "The {field} field must be at least {min}.",
+	"max":      "The {field} field must not be greater than {max}.",
+	"between":  "The {field} field must be between {min} and {max}.",
+	"in":       "The selected {field} is invalid.",
+	"unique":   "The {field} has already been taken.",
+}
+
+// ValidationError is a rule a field failed.
+//   - Field: The path of the field, nested fields and list items are separated by dots, e.g. "items.0.name".
+//   - Rule: The code of the rule, e.g. "max".
+//   - Params: The parameters of the rule, they fill the placeholders of the message template, e.g. {"max": 10}.
+//   - Message: The message, when it is empty the message template of the rule is used.
+type ValidationError struct {
+	Field   string
+	Rule    string
+	Params  map[string]any
+	Message string
+}
+
+// ValidationErrors is a bag of the rules the fields of a request failed, in the order they are added.
+// It implements error with the 422 status, so it can be returned by a [Handler].
+type ValidationErrors struct {
+	errors []ValidationError
+}
+
+// NewValidationErrors creates an empty [ValidationErrors]
+func NewValidationErrors() *ValidationErrors {
+	return &ValidationErrors{}
+}
+
+// Add adds a failed rule of the field, message overrides the message template of the rule
+func (bag *ValidationErrors) Add(field, rule string, params map[string]any, message ...string) *ValidationErrors {
+	validationError := ValidationError{Field: field, Rule: rule, Params: params}
+	if len(message) > 0 {
+		validationError.Message = message[0]
+	}
+	bag.errors = append(bag.errors, validationError)
+	return bag
+}
+
+// Errors returns the failed rules
+func (bag *ValidationErrors) Errors() []ValidationError {
+	return bag.errors
+}
+
+// Empty returns if no rule failed
+func (bag *ValidationErrors) Empty() bool {
+	return len(bag.errors) == 0
+}
+
+// Has returns if the field failed a rule
+func (bag *ValidationErrors) Has(field string) bool {
+	for _, validationError := range bag.errors {
+		if validationError.Field == field {
+			return true
+		}
+	}
+	return false
+}
+
+// Fields returns the fields which failed a rule, in the order they are added
+func (bag *ValidationErrors) Fields() []string {
+	var fields []string
+	seen := make(map[string]bool)
+	for _, validationError := range bag.errors {
+		if !seen[validationError.Field] {
+			seen[validationError.Field] = true
+			fields = append(fields, validationError.Field)
+		}
+	}
+	return fields
+}
+
+// Error returns the English messages of the failed rules
+func (bag *ValidationErrors) Error() string {
+	messages := make([]string, 0, len(bag.errors))
+	for _, validationError := range bag.errors {
+		messages = append(messages, validationMessage(validationError, nil))
+	}
+	return strings.Join(messages, " ")
+}
+
+// StatusCode returns 422 Unprocessable Entity
+func (bag *ValidationErrors) StatusCode() int {
+	return http.StatusUnprocessableEntity
+}
+
+// ValidationErrorResponse is used to report the fields of a request which failed validation.
+// The format is chosen from the Accept header of the request:
+//   - application/problem+json: A problem details object with an "errors" extension listing the failed rules.
+//   - text/html preferred over JSON: A 303 redirect back to the form (SetRedirect, or the Referer when it is on the host of the
+//     request, the JSON object is sent otherwise) with the messages flashed
+//     in the [ValidationFlashCookie] cookie, which the form reads with [FlashedValidationErrors].
+//     The cookie is kept under 3 KB, long lists are cut down to the first message of the first fields.
+//   - Otherwise: A JSON object whose "errors" member maps the fields to their failed rules and messages.
+//
+// Messages come from the templates of the locale (AddMessages), picked with SetLocale or from the Accept-Language
+// header, falling back to English. A template is looked up by "<field>.<rule>" then by rule, {field} and
+// {<param>} placeholders are replaced with the field and the rule parameters.
+type ValidationErrorResponse struct {
+	*Response
+	errors   *ValidationErrors
+	messages map[string]map[string]string
+	locale   string
+	redirect string
+}
+
+// ValidationFailed returns a 422 [ValidationErrorResponse]
+func ValidationFailed(errors *ValidationErrors) *ValidationErrorResponse {
+	return New(http.StatusUnprocessableEntity).Validation(errors)
+}
+
+// SetErrors sets the failed rules
+func (validationResponse *ValidationErrorResponse) SetErrors(errors *ValidationErrors) *ValidationErrorResponse {
+	validationResponse.errors = errors
+	return validationResponse
+}
+
+// AddMessages adds message templates of a locale, keyed by rule or by "<field>.<rule>"
+func (validationResponse *ValidationErrorResponse) AddMessages(locale string, templates map[string]string) *ValidationErrorResponse {
+	if validationResponse.messages == nil {
+		validationResponse.messages = make(map[string]map[string]string)
+	}
+	locale = strings.ToLower(locale)
+	if validationResponse.messages[locale] == nil {
+		validationResponse.messages[locale] = make(map[string]string, len(templates))
+	}
+	for key, template := range templates {
+		validationResponse.messages[locale][key] = template
+	}
+	return validationResponse
+}
+
+// SetLocale sets the locale of the messages, it overrides the Accept-Language header
+func (validationResponse *ValidationErrorResponse) SetLocale(locale string) *ValidationErrorResponse {
+	validationResponse.locale = strings.ToLower(locale)
+	return validationResponse
+}
+
+// SetRedirect sets where HTML forms are redirected back to, the Referer of the request is used by default when it is on the
+// host of the request
+func (validationResponse *ValidationErrorResponse) SetRedirect(location string) *ValidationErrorResponse {
+	validationResponse.redirect = location
+	return validationResponse
+}
+
+// Messages returns the messages of the fields in the locale
+func (validationResponse *ValidationErrorResponse) Messages(locale string) map[string][]string {
+	templates := validationResponse.messages[strings.ToLower(locale)]
+	messages := make(map[string][]string)
+	for _, validationError := range validationResponse.errors.Errors() {
+		messages[validationError.Field] = append(messages[validationError.Field], validationMessage(validationError, templates))
+	}
+	return messages
+}
+
+// ServeHTTP sends the errors in the format accepted by the request
+func (validationResponse *ValidationErrorResponse) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	validationResponse.send(w, r, validationResponse.serve)
+}
+
+func (validationResponse *ValidationErrorResponse) serve(w http.ResponseWriter, r *http.Request) {
+	if validationResponse.errors == nil {
+		validationResponse.errors = NewValidationErrors()
+	}
+	locale := validationResponse.negotiateLocale(r)
+	templates := validationResponse.messages[locale]
+	// the listed format with the highest quality is used, problem details then JSON on a tie, and JSON when none is listed
+	switch negotiateMediaType(parseAccept(r.Header.Get("Accept")), isMediaType("application/problem+json"), isJSONMediaType, isMediaType("text/html")) {
+	case 0:
+		problem := NewProblemDetails(validationResponse.statusCode, "The given data was invalid.")
+		problem.Instance = r.URL.Path
+		problemErrors := make([]map[string]any, 0, len(validationResponse.errors.errors))
+		for _, validationError := range validationResponse.errors.errors {
+			problemError := map[string]any{
+				"field":   validationError.Field,
+				"pointer": "#/" + strings.ReplaceAll(validationError.Field, ".", "/"),
+				"rule":    validationError.Rule,
+				"detail":  validationMessage(validationError, templates),
+			}
+			if len(validationError.Params) > 0 {
+				problemError["params"] = validationError.Params
+			}
+			problemErrors = append(problemErrors, problemError)
+		}
+		problem.Extensions = map[string]any{"errors": problemErrors}
+		// the media type is set on a copy so the response can still be served to other formats
+		response := *validationResponse.Response
+		response.headers = validationResponse.headers.Clone()
+		if !response.HasHeader("Content-Type") {
+			response.SetHeader("Content-Type", "application/problem+json")
+		}
+		(&JSONResponse{Response: &response, data: problem}).serve(w, r)
+	case 2:
+		location := validationResponse.redirect
+		if location == "" {
+			location = sameHostReferer(r)
+		}
+		if location == "" {
+			validationResponse.serveJSON(w, r, templates)
+			return
+		}
+		// the redirect is sent by a copy so the response can still be served to other formats
+		redirect := *validationResponse.Response
+		redirect.cookies = append(slices.Clone(redirect.cookies), newValidationFlashCookie(validationResponse.Messages(locale)))
+		redirect.statusCode = http.StatusSeeOther
+		redirect.Redirect(location).serve(w, r)
+	default:
+		validationResponse.serveJSON(w, r, templates)
+	}
+}
+
+// serveJSON sends the errors as a JSON object
+func (validationResponse *ValidationErrorResponse) serveJSON(w http.ResponseWriter, r *http.Request, templates map[string]string) {
+	fields := make(map[string][]map[string]any)
+	for _, validationError := range validationResponse.errors.errors {
+		fieldError := map[string]any{
+			"rule":    validationError.Rule,
+			"message": validationMessage(validationError, templates),
+		}
+		if len(validationError.Params) > 0 {
+			fieldError["params"] = validationError.Params
+		}
+		fields[validationError.Field] = append(fields[validationError.Field], fieldError)
+	}
+	response := *validationResponse.Response
+	response.headers = validationResponse.headers.Clone()
+	(&JSONResponse{Response: &response, data: map[string]any{
+		"message": "The given data was invalid.",
+		"errors":  fields,
+	}}).serve(w, r)
+}
+
+// sameHostReferer returns the Referer of the request when it is on the host of the request, so a form is not redirected
+// to another site, or an empty string. The scheme is not compared as TLS is often terminated before the server.
+func sameHostReferer(r *http.Request) string {
+	referer := r.Header.Get("Referer")
+	location, err := url.Parse(referer)
+	if err != nil || referer == "" {
+		return ""
+	}
+	if location.Scheme == "" && location.Host == "" {
+		// a path, browsers read a leading /\ as //
+		if !strings.HasPrefix(location.Path, "/") || strings.HasPrefix(location.Path, "/\\") {
+			return ""
+		}
+		return referer
+	}
+	if (location.Scheme != "http" && location.Scheme != "https") || location.User != nil || !strings.EqualFold(location.Host, r.Host) {
+		return ""
+	}
+	return referer
+}
+
+// negotiateLocale returns the locale set on the response or the preferred locale of the request which has messages
+func (validationResponse *ValidationErrorResponse) negotiateLocale(r *http.Request) string {
+	if validationResponse.locale != "" {
+		return validationResponse.locale
+	}
+	type languageRange struct {
+		tag     string
+		quality float64
+	}
+	var ranges []languageRange
+	for _, part := range strings.Split(r.Header.Get("Accept-Language"), ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if tag == "" {
+			continue
+		}
+		quality := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if q, err := strconv.ParseFloat(value, 64); err == nil {
+				quality = q
+			}
+		}
+		// q=0 means the language is not acceptable
+		if quality <= 0 {
+			continue
+		}
+		ranges = append(ranges, languageRange{tag: strings.ToLower(tag), quality: quality})
+	}
+	sort.SliceStable(ranges, func(i, j int) bool {
+		return ranges[i].quality > ranges[j].quality
+	})
+	for _, preferred := range ranges {
+		if _, ok := validationResponse.messages[preferred.tag]; ok {
+			return preferred.tag
+		}
+		base, _, _ := strings.Cut(preferred.tag, "-")
+		if _, ok := validationResponse.messages[base]; ok {
+			return base
+		}
+	}
+	return ""
+}
+
+// validationMessage returns the message of the failed rule using the templates, falling back to English
+func validationMessage(validationError ValidationError, templates map[string]string) string {
+	if validationError.Message != "" {
+		return validationError.Message
+	}
+	template, ok := templates[validationError.Field+"."+validationError.Rule]
+	if !ok {
+		template, ok = templates[validationError.Rule]
+	}
+	if !ok {
+		template, ok = defaultValidationMessages[validationError.Rule]
+	}
+	if !ok {
+		template = "The {field} field is invalid."
+	}
+	replacements := []string{"{field}", strings.ReplaceAll(validationError.Field, "_", " ")}
+	for name, value := range validationError.Params {
+		replacements = append(replacements, "{"+name+"}", fmt.Sprint(value))
+	}
+	return strings.NewReplacer(replacements...).Replace(template)
+}
+
+// validationFlashCookieSize is the size limit of the flash cookie,
+// below [MaxCookieSize] to leave room for the attributes a cookie policy adds
+const validationFlashCookieSize = 3 << 10
+
+// newValidationFlashCookie returns the cookie flashing the messages. When it would exceed the size limit,
+// only the first message of each field is kept, then the last fields are dropped until it fits.
+func newValidationFlashCookie(messages map[string][]string) *http.Cookie {
+	cookie := &http.Cookie{
+		Name:     ValidationFlashCookie,
+		Path:     "/",
+		MaxAge:   int(time.Minute / time.Second),
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	}
+	encode := func(messages map[string][]string) bool {
+		content, err := json.Marshal(messages)
+		if err != nil {
+			panic(err)
+		}
+		cookie.Value = base64.RawURLEncoding.EncodeToString(content)
+		return len(cookie.String()) <= validationFlashCookieSize
+	}
+	if encode(messages) {
+		return cookie
+	}
+	fields := make([]string, 0, len(messages))
+	firsts := make(map[string][]string, len(messages))
+	for field, fieldMessages := range messages {
+		fields = append(fields, field)
+		firsts[field] = fieldMessages[:min(len(fieldMessages), 1)]
+	}
+	sort.Strings(fields)
+	for len(fields) > 0 && !encode(firsts) {
+		delete(firsts, fields[len(fields)-1])
+		fields = fields[:len(fields)-1]
+	}
+	return cookie
+}
+
+// FlashedValidationErrors returns the messages flashed by a [ValidationErrorResponse] redirecting a form back,
+// the page showing them should forget the [ValidationFlashCookie] cookie
+func FlashedValidationErrors(r *http.Request) (map[string][]string, bool) {
+	cookie, err := r.Cookie(ValidationFlashCookie)
+	if err != nil {
+		return nil, false
+	}
+	content, err := base64.RawURLEncoding.DecodeString(cookie.Value)
+	if err != nil {
+		return nil, false
+	}
+	var messages map[string][]string
+	if err := json.Unmarshal(content, &messages); err != nil {
+		return nil, false
+	}
+	return messages, true
+}
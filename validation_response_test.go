@@ -0,0 +1,231 @@
+package response
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestValidationErrors() *ValidationErrors {
+	return NewValidationErrors().
+		Add("email", "required", nil).
+		Add("items.0.quantity", "max", map[string]any{"max": 10}).
+		Add("name", "custom", nil, "Pick another name.")
+}
+
+func TestValidationErrors(t *testing.T) {
+	bag := newTestValidationErrors()
+	assert.False(t, bag.Empty())
+	assert.True(t, bag.Has("email"))
+	assert.False(t, bag.Has("password"))
+	assert.Equal(t, []string{"email", "items.0.quantity", "name"}, bag.Fields())
+	assert.Equal(t, "The email field is required. The items.0.quantity field must not be greater than 10. Pick another name.", bag.Error())
+
+	var err error = bag
+	assert.Equal(t, http.StatusUnprocessableEntity, AsHTTPError(err, false).StatusCode)
+}
+
+func TestValidationErrorResponse_JSON(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	ValidationFailed(newTestValidationErrors()).ServeHTTP(recorder, httptest.NewRequest("POST", "/orders", nil))
+	assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+	assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{
+		"message": "The given data was invalid.",
+		"errors": {
+			"email": [{"rule": "required", "message": "The email field is required."}],
+			"items.0.quantity": [{"rule": "max", "message": "The items.0.quantity field must not be greater than 10.", "params": {"max": 10}}],
+			"name": [{"rule": "custom", "message": "Pick another name."}]
+		}
+	}`, recorder.Body.String())
+}
+
+func TestValidationErrorResponse_Formats(t *testing.T) {
+	response := ValidationFailed(newTestValidationErrors())
+	request := httptest.NewRequest("POST", "/orders", nil)
+	request.Header.Set("Accept", "application/problem+json")
+	recorder := httptest.NewRecorder()
+	response.ServeHTTP(recorder, request)
+	assert.Equal(t, "application/problem+json", recorder.Header().Get("Content-Type"))
+	assert.False(t, response.HasHeader("Content-Type"))
+
+	recorder = httptest.NewRecorder()
+	response.ServeHTTP(recorder, httptest.NewRequest("POST", "/orders", nil))
+	assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+	assert.Contains(t, recorder.Body.String(), `"message":"The given data was invalid."`)
+
+	cases := []struct {
+		accept string
+		code   int
+	}{
+		{"text/html, application/json;q=0", http.StatusSeeOther},
+		{"text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", http.StatusSeeOther},
+		{"text/html;q=0.5, application/json", http.StatusUnprocessableEntity},
+		{"text/html, application/json", http.StatusUnprocessableEntity},
+		{"text/x-html-foo", http.StatusUnprocessableEntity},
+		{"application/problem+json;q=0, text/html", http.StatusSeeOther},
+	}
+	for _, c := range cases {
+		request := httptest.NewRequest("POST", "/orders", nil)
+		request.Header.Set("Accept", c.accept)
+		request.Header.Set("Referer", "/orders/new")
+		recorder := httptest.NewRecorder()
+		response.ServeHTTP(recorder, request)
+		assert.Equal(t, c.code, recorder.Code, c.accept)
+	}
+}
+
+func TestValidationErrorResponse_Problem(t *testing.T) {
+	request := httptest.NewRequest("POST", "/orders", nil)
+	request.Header.Set("Accept", "application/problem+json")
+	recorder := httptest.NewRecorder()
+	ValidationFailed(NewValidationErrors().Add("items.0.quantity", "max", map[string]any{"max": 10})).ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+	assert.Equal(t, "application/problem+json", recorder.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{
+		"type": "about:blank",
+		"title": "Unprocessable Entity",
+		"status": 422,
+		"detail": "The given data was invalid.",
+		"instance": "/orders",
+		"errors": [{
+			"field": "items.0.quantity",
+			"pointer": "#/items/0/quantity",
+			"rule": "max",
+			"params": {"max": 10},
+			"detail": "The items.0.quantity field must not be greater than 10."
+		}]
+	}`, recorder.Body.String())
+}
+
+func TestValidationErrorResponse_Redirect(t *testing.T) {
+	request := httptest.NewRequest("POST", "/register", nil)
+	request.Header.Set("Accept", "text/html,application/xhtml+xml")
+	request.Header.Set("Referer", "/register?step=1")
+	response := ValidationFailed(newTestValidationErrors())
+	recorder := httptest.NewRecorder()
+	response.ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusSeeOther, recorder.Code)
+	assert.Equal(t, "/register?step=1", recorder.Header().Get("Location"))
+	assert.Equal(t, http.StatusUnprocessableEntity, response.StatusCode())
+	assert.Empty(t, response.Cookies())
+
+	next := httptest.NewRequest("GET", "/register?step=1", nil)
+	for _, cookie := range recorder.Result().Cookies() {
+		next.AddCookie(cookie)
+	}
+	messages, ok := FlashedValidationErrors(next)
+	assert.True(t, ok)
+	assert.Equal(t, map[string][]string{
+		"email":            {"The email field is required."},
+		"items.0.quantity": {"The items.0.quantity field must not be greater than 10."},
+		"name":             {"Pick another name."},
+	}, messages)
+
+	_, ok = FlashedValidationErrors(httptest.NewRequest("GET", "/", nil))
+	assert.False(t, ok)
+}
+
+func TestValidationErrorResponse_RedirectReferer(t *testing.T) {
+	cases := []struct {
+		referer  string
+		location string
+	}{
+		{"http://example.com/register", "http://example.com/register"},
+		{"https://EXAMPLE.com/register?step=2", "https://EXAMPLE.com/register?step=2"},
+		{"https://evil.example/register", ""},
+		{"//evil.example/register", ""},
+		{"/\\evil.example/register", ""},
+		{"https://example.com@evil.example/", ""},
+		{"javascript:alert(1)", ""},
+		{"register", ""},
+		{"", ""},
+	}
+	for _, c := range cases {
+		request := httptest.NewRequest("POST", "http://example.com/register", nil)
+		request.Header.Set("Accept", "text/html")
+		request.Header.Set("Referer", c.referer)
+		recorder := httptest.NewRecorder()
+		ValidationFailed(newTestValidationErrors()).ServeHTTP(recorder, request)
+		if c.location == "" {
+			assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code, c.referer)
+			assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"), c.referer)
+			assert.Empty(t, recorder.Result().Cookies(), c.referer)
+			continue
+		}
+		assert.Equal(t, http.StatusSeeOther, recorder.Code, c.referer)
+		assert.Equal(t, c.location, recorder.Header().Get("Location"), c.referer)
+	}
+
+	request := httptest.NewRequest("POST", "http://example.com/register", nil)
+	request.Header.Set("Accept", "text/html")
+	request.Header.Set("Referer", "https://evil.example/register")
+	recorder := httptest.NewRecorder()
+	ValidationFailed(newTestValidationErrors()).SetRedirect("/register").ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusSeeOther, recorder.Code)
+	assert.Equal(t, "/register", recorder.Header().Get("Location"))
+}
+
+func TestValidationErrorResponse_FlashCookieSize(t *testing.T) {
+	validationErrors := NewValidationErrors()
+	for i := 0; i < 100; i++ {
+		field := fmt.Sprintf("items.%02d.name", i)
+		validationErrors.Add(field, "required", nil).Add(field, "custom", nil, strings.Repeat("x", 40))
+	}
+	request := httptest.NewRequest("POST", "/orders", nil)
+	request.Header.Set("Accept", "text/html")
+	request.Header.Set("Referer", "/orders/new")
+	recorder := httptest.NewRecorder()
+	ValidationFailed(validationErrors).ServeHTTP(recorder, request)
+
+	header := recorder.Header().Get("Set-Cookie")
+	assert.Less(t, len(header), MaxCookieSize)
+	next := httptest.NewRequest("GET", "/orders", nil)
+	for _, cookie := range recorder.Result().Cookies() {
+		next.AddCookie(cookie)
+	}
+	messages, ok := FlashedValidationErrors(next)
+	assert.True(t, ok)
+	assert.NotEmpty(t, messages)
+	assert.Less(t, len(messages), 100)
+	assert.Equal(t, []string{"The items.00.name field is required."}, messages["items.00.name"])
+}
+
+func TestValidationErrorResponse_Locale(t *testing.T) {
+	newResponse := func() *ValidationErrorResponse {
+		return ValidationFailed(NewValidationErrors().Add("email", "required", nil).Add("age", "min", map[string]any{"min": 18})).
+			AddMessages("fr", map[string]string{
+				"required": "Le champ {field} est obligatoire.",
+				"age.min":  "Vous devez avoir au moins {min} ans.",
+			})
+	}
+
+	request := httptest.NewRequest("POST", "/", nil)
+	request.Header.Set("Accept-Language", "de;q=0.9, fr-CA, en;q=0.5")
+	assert.Equal(t, map[string][]string{
+		"email": {"Le champ email est obligatoire."},
+		"age":   {"Vous devez avoir au moins 18 ans."},
+	}, newResponse().Messages(newResponse().negotiateLocale(request)))
+
+	assert.Equal(t, "", newResponse().negotiateLocale(httptest.NewRequest("POST", "/", nil)))
+	request.Header.Set("Accept-Language", "fr;q=0, en")
+	assert.Equal(t, "", newResponse().negotiateLocale(request))
+	request.Header.Set("Accept-Language", "de;q=0.9, fr-CA, en;q=0.5")
+	assert.Equal(t, "de", newResponse().SetLocale("DE").negotiateLocale(request))
+	assert.Equal(t, []string{"The email field is required."}, newResponse().Messages("de")["email"])
+}
+
+func TestHandle_ValidationErrors(t *testing.T) {
+	handler := Handle(func(r *http.Request) (http.Handler, error) {
+		return nil, errors.Join(NewValidationErrors().Add("email", "email", nil))
+	})
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest("POST", "/", nil))
+	assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+	assert.JSONEq(t, `{"message":"The given data was invalid.","errors":{"email":[{"rule":"email","message":"The email field must be a valid email address."}]}}`, recorder.Body.String())
+}
@@ -0,0 +1,71 @@
+package response
+
+import (
+	"bytes"
+	"html/template"
+	"io"
+	"net/http"
+
+	"github.com/gopi-frame/exception"
+)
+
+// TemplateEngine renders named templates, it is used by [ViewResponse]
+type TemplateEngine interface {
+	Render(w io.Writer, name string, data any) error
+}
+
+// HTMLTemplateEngine is a [TemplateEngine] backed by [template.Template]
+type HTMLTemplateEngine struct {
+	templates *template.Template
+}
+
+// NewHTMLTemplateEngine creates a new [HTMLTemplateEngine] which executes the templates associated with templates
+func NewHTMLTemplateEngine(templates *template.Template) *HTMLTemplateEngine {
+	return &HTMLTemplateEngine{templates: templates}
+}
+
+// Render executes the named template
+func (engine *HTMLTemplateEngine) Render(w io.Writer, name string, data any) error {
+	return engine.templates.ExecuteTemplate(w, name, data)
+}
+
+// ViewResponse is used to send a page rendered by a [TemplateEngine], the page is rendered when the response is sent
+type ViewResponse struct {
+	*Response
+	engine TemplateEngine
+	name   string
+	data   any
+}
+
+// SetEngine sets the template engine
+func (viewResponse *ViewResponse) SetEngine(engine TemplateEngine) *ViewResponse {
+	viewResponse.engine = engine
+	return viewResponse
+}
+
+// SetView sets the template name and the data it is rendered with
+func (viewResponse *ViewResponse) SetView(name string, data any) *ViewResponse {
+	viewResponse.name = name
+	viewResponse.data = data
+	return viewResponse
+}
+
+// ServeHTTP renders the template and sends it
+func (viewResponse *ViewResponse) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	viewResponse.send(w, r, viewResponse.serve)
+}
+
+func (viewResponse *ViewResponse) serve(w http.ResponseWriter, r *http.Request) {
+	if viewResponse.engine == nil {
+		panic(exception.New("template engine is not set"))
+	}
+	buf := new(bytes.Buffer)
+	if err := viewResponse.engine.Render(buf, viewResponse.name, viewResponse.data); err != nil {
+		panic(err)
+	}
+	viewResponse.content = buf.Bytes()
+	if !viewResponse.HasHeader("Content-Type") {
+		viewResponse.SetHeader("Content-Type", "text/html; charset=utf-8")
+	}
+	viewResponse.Response.serve(w, r)
+}
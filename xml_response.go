@@ -5,10 +5,20 @@ import (
 	"net/http"
 )
 
+// XMLOptions changes how XML responses are encoded.
+//   - Prefix and Indent: When set, the XML is indented as by [xml.MarshalIndent].
+//   - Header: When true, the document starts with [xml.Header].
+type XMLOptions struct {
+	Prefix string
+	Indent string
+	Header bool
+}
+
 // XMLResponse is used to send a XML response
 type XMLResponse struct {
 	*Response
-	data any
+	data    any
+	options XMLOptions
 }
 
 // SetContent sets response body content
@@ -16,13 +26,28 @@ func (xmlResponse *XMLResponse) SetContent(data any) {
 	xmlResponse.data = data
 }
 
+// SetOptions sets the encoding options
+func (xmlResponse *XMLResponse) SetOptions(options XMLOptions) *XMLResponse {
+	xmlResponse.options = options
+	return xmlResponse
+}
+
 // ServeHTTP sends the response
 func (xmlResponse *XMLResponse) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	xmlBytes, err := xml.Marshal(xmlResponse.data)
+	xmlResponse.send(w, r, xmlResponse.serve)
+}
+
+func (xmlResponse *XMLResponse) serve(w http.ResponseWriter, r *http.Request) {
+	xmlBytes, err := xml.MarshalIndent(xmlResponse.data, xmlResponse.options.Prefix, xmlResponse.options.Indent)
 	if err != nil {
 		panic(err)
 	}
+	if xmlResponse.options.Header {
+		xmlBytes = append([]byte(xml.Header), xmlBytes...)
+	}
 	xmlResponse.content = xmlBytes
-	xmlResponse.SetHeader("content-type", "application/xml")
-	xmlResponse.Response.ServeHTTP(w, r)
+	if !xmlResponse.HasHeader("content-type") {
+		xmlResponse.SetHeader("content-type", "application/xml")
+	}
+	xmlResponse.Response.serve(w, r)
 }